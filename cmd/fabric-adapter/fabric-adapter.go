@@ -8,6 +8,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"math"
 	"net"
 	"net/http"
 	"os"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/google/gnxi/utils/credentials"
 	"github.com/onosproject/fabric-adapter/internal/pkg/version"
+	"github.com/onosproject/fabric-adapter/pkg/store"
 	synchronizer "github.com/onosproject/fabric-adapter/pkg/synchronizer"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/onosproject/sdcore-adapter/pkg/diagapi"
@@ -30,7 +32,10 @@ import (
 var (
 	bindAddr             = flag.String("bind_address", ":10161", "Bind to address:port or just :port")
 	metricAddr           = flag.String("metric_address", ":9851", "Prometheus metric endpoint bind to address:port or just :port")
+	inventoryAddr        = flag.String("inventoryAddress", ":9852", "Switch inventory and SID API bind to address:port or just :port")
 	partialUpdateDisable = flag.Bool("partial_update_disable", false, "Disable partial update; send full updates to core on every change")
+	appActivateDisable   = flag.Bool("appActivateDisable", false, "Disable activating missing ONOS applications; report them as errors instead")
+	stratumVerifyEnable  = flag.Bool("stratumVerifyEnable", synchronizer.DefaultStratumVerifyEnable, "Read back the chassis config after pushing it to a switch and fail the push if it differs")
 	postDisable          = flag.Bool("post_disable", false, "Disable posting to connectivity service endpoints")
 	postTimeout          = flag.Duration("post_timeout", time.Second*10, "Timeout duration when making post requests")
 	aetherConfigAddr     = flag.String("aether_config_addr", "", "If specified, pull initial state from aether-config at this address")
@@ -41,6 +46,8 @@ var (
 	keyPath              = flag.String("keyPath", "", "path to client private key")
 	certPath             = flag.String("certPath", "", "path to client certificate")
 	topoEndpoint         = flag.String("topoEndpoint", "onos-topo:5150", "onos-topo endpoint address")
+	sidRangeStart        = flag.Uint("sidRangeStart", store.DefaultSIDRangeStart, "First segment routing node SID to allocate")
+	sidRangeEnd          = flag.Uint("sidRangeEnd", store.DefaultSIDRangeEnd, "Last segment routing node SID to allocate")
 )
 
 var log = logging.GetLogger("fabric-adapter")
@@ -52,6 +59,12 @@ func serveMetrics() {
 	}
 }
 
+func serveInventory(handler http.Handler) {
+	if err := http.ListenAndServe(*inventoryAddr, handler); err != nil {
+		log.Fatalf("failed to serve inventory: %v", err)
+	}
+}
+
 // Synchronize and eat the error. This lets aether-config know we applied the
 // configuration, but leaves us to retry applying it to the southbound device
 // ourselves.
@@ -68,6 +81,7 @@ func synchronizerWrapper(s synchronizer.SynchronizerInterface) gnmi.ConfigCallba
 
 func main() {
 	var sync synchronizer.SynchronizerInterface
+	var fabricSync *synchronizer.Synchronizer
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -75,18 +89,30 @@ func main() {
 	}
 	flag.Parse()
 
+	// SIDs are 32 bits wide; catch a bad range here rather than truncating it
+	if *sidRangeStart > math.MaxUint32 || *sidRangeEnd > math.MaxUint32 {
+		log.Fatalf("SID range %d-%d exceeds the maximum SID %d", *sidRangeStart, *sidRangeEnd, uint32(math.MaxUint32))
+	}
+	if *sidRangeStart == 0 || *sidRangeStart > *sidRangeEnd {
+		log.Fatalf("invalid SID range %d-%d", *sidRangeStart, *sidRangeEnd)
+	}
+
 	log.Infof("fabric-adapter")
 	version.LogVersion("  ")
 
 	// Initialize the synchronizer's service-specific code.
 	log.Infof("Initializing synchronizer")
-	sync = synchronizer.NewSynchronizer(
+	fabricSync = synchronizer.NewSynchronizer(
 		synchronizer.WithPostEnable(!*postDisable),
 		synchronizer.WithPartialUpdateEnable(!*partialUpdateDisable),
+		synchronizer.WithAppActivateEnable(!*appActivateDisable),
+		synchronizer.WithStratumVerifyEnable(*stratumVerifyEnable),
 		synchronizer.WithPostTimeout(*postTimeout),
 		synchronizer.WithCertPaths(*caPath, *keyPath, *certPath),
 		synchronizer.WithTopoEndpoint(*topoEndpoint),
+		synchronizer.WithSIDRange(uint32(*sidRangeStart), uint32(*sidRangeEnd)),
 	)
+	sync = fabricSync
 
 	// The synchronizer will convey its list of models.
 	model := sync.GetModels()
@@ -129,6 +155,9 @@ func main() {
 	log.Info("starting metric handler")
 	go serveMetrics()
 
+	log.Infof("starting inventory API on %s", *inventoryAddr)
+	go serveInventory(fabricSync.NewInventoryHandler())
+
 	log.Infof("starting out-of-band API on %d", *diagsPort)
 	diagapi.StartDiagnosticAPI(s, *aetherConfigAddr, *aetherConfigTarget, *diagsPort)
 
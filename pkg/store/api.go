@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+/*
+ * api.go: a read-only HTTP API for the SID inventory, served on the inventory API port of
+ * fabric-adapter (see its inventoryAddress flag)
+ *
+ * Examples:
+ *   # list all SID allocations
+ *   curl http://localhost:9852/sids
+ *
+ *   # look up the SID owned by a switch
+ *   curl http://localhost:9852/sids/leaf-one
+ *
+ *   # stream allocation changes as newline delimited JSON, starting with the existing allocations
+ *   curl "http://localhost:9852/sids?watch=true&replay=true"
+ */
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// SIDHandlerPrefix is the path the SID API is served under
+const SIDHandlerPrefix = "/sids"
+
+// SIDHandler serves the SID inventory over HTTP
+type SIDHandler struct {
+	sidStore SIDStore
+}
+
+// NewSIDHandler creates an HTTP handler for the given SID store
+func NewSIDHandler(sidStore SIDStore) *SIDHandler {
+	return &SIDHandler{sidStore: sidStore}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *SIDHandler) list(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.sidStore.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, entries)
+}
+
+func (h *SIDHandler) lookup(w http.ResponseWriter, r *http.Request, switchID string) {
+	sid, err := h.sidStore.Lookup(r.Context(), switchID)
+	if errors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, SIDEntry{SwitchID: switchID, SID: sid})
+}
+
+func (h *SIDHandler) watch(w http.ResponseWriter, r *http.Request, replay bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan SIDEvent)
+	err := h.sidStore.Watch(r.Context(), ch, replay)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+	for event := range ch {
+		err = encoder.Encode(event)
+		if err != nil {
+			log.Warnf("Error writing SID event: %v", err)
+			continue
+		}
+		flusher.Flush()
+	}
+}
+
+// ServeHTTP dispatches a request to the list, lookup or watch operation
+func (h *SIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switchID := strings.Trim(strings.TrimPrefix(r.URL.Path, SIDHandlerPrefix), "/")
+	if switchID != "" {
+		h.lookup(w, r, switchID)
+		return
+	}
+
+	queryArgs := r.URL.Query()
+	if watch, _ := strconv.ParseBool(queryArgs.Get("watch")); watch {
+		replay, _ := strconv.ParseBool(queryArgs.Get("replay"))
+		h.watch(w, r, replay)
+		return
+	}
+	h.list(w, r)
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSIDHandler checks the list and lookup endpoints of the SID API
+func TestSIDHandler(t *testing.T) {
+	testAtomix, sidStore := getAtomixStore(t)
+	sid, err := sidStore.Get(context.Background(), "leaf-one")
+	assert.NoError(t, err)
+
+	ts := httptest.NewServer(NewSIDHandler(sidStore))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + SIDHandlerPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var entries []SIDEntry
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, []SIDEntry{{SwitchID: "leaf-one", SID: sid}}, entries)
+
+	resp, err = http.Get(ts.URL + SIDHandlerPrefix + "/leaf-one")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var entry SIDEntry
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&entry))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, SIDEntry{SwitchID: "leaf-one", SID: sid}, entry)
+
+	resp, err = http.Get(ts.URL + SIDHandlerPrefix + "/leaf-two")
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(ts.URL+SIDHandlerPrefix, "application/json", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	assert.NoError(t, testAtomix.Stop())
+}
@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// KpiSIDRemaining is the number of SIDs that are still available for allocation
+	KpiSIDRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "sid_remaining",
+		Help: "The number of SIDs remaining in the configured SID range",
+	})
+)
@@ -7,9 +7,10 @@ package store
 import (
 	"context"
 	"encoding/binary"
-	"github.com/atomix/atomix-go-client/pkg/atomix/counter"
 	atomixerrors "github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"io"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/atomix/atomix-go-client/pkg/atomix"
@@ -21,60 +22,163 @@ import (
 var log = logging.GetLogger()
 
 const (
-	// SidCounter is the name used for atomix counter for generating unique SIDs
+	// SidCounter is the name of the atomix counter SIDs used to be generated from. It is only read
+	// to carry the last SID it handed out over to SidAllocationMap.
 	SidCounter = "fabric-adapter-sid-counter"
 
+	// SidAllocationMap is the name of the atomix map holding the last SID handed out. It is advanced
+	// with compare-and-set, so replicas never hand out the same SID or move it past the range.
+	SidAllocationMap = "fabric-adapter-sid-allocation"
+
 	// SidMap is the name used for atomix counter for generating unique SIDs
 	SidMap = "fabric-adapter-sid-map"
+
+	// DefaultSIDRangeStart is the first SID handed out; the SIDs below it are reserved for segment routing
+	DefaultSIDRangeStart = 101
+
+	// DefaultSIDRangeEnd is the last SID handed out when no upper bound is configured
+	DefaultSIDRangeEnd = math.MaxUint32
+
+	// lastSIDKey is the SidAllocationMap key of the last SID handed out
+	lastSIDKey = "last"
 )
 
+// Option is for options passed when creating a new store
+type Option func(s *SIDAtomixStore)
+
+// WithSIDRange restricts the SIDs allocated by the store to the inclusive range [start, end]
+func WithSIDRange(start uint32, end uint32) Option {
+	return func(s *SIDAtomixStore) {
+		s.rangeStart = start
+		s.rangeEnd = end
+	}
+}
+
 // NewAtomixStore returns a new persistent Store
-func NewAtomixStore(ctx context.Context, atomixClient atomix.Client) (SIDStore, error) {
+func NewAtomixStore(ctx context.Context, atomixClient atomix.Client, opts ...Option) (SIDStore, error) {
+	store := &SIDAtomixStore{
+		rangeStart: DefaultSIDRangeStart,
+		rangeEnd:   DefaultSIDRangeEnd,
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	if store.rangeStart == 0 || store.rangeStart > store.rangeEnd {
+		return nil, errors.NewInvalid("invalid SID range %d-%d", store.rangeStart, store.rangeEnd)
+	}
 
-	nextSID, err := atomixClient.GetCounter(ctx, SidCounter)
+	allocationMap, err := atomixClient.GetMap(ctx, SidAllocationMap)
 	if err != nil {
-		log.Warnf("Error creating atomix counter: %v", err)
+		log.Warnf("Error creating atomix map: %v", err)
 		return nil, err
 	}
-	startingValue, err := nextSID.Get(ctx)
+	err = carryOverSidCounter(ctx, atomixClient, allocationMap)
 	if err != nil {
-		log.Warnf("Error querying atomix counter: %v", err)
 		return nil, err
 	}
-	if startingValue < 100 {
-		// Reserve the first 100 SIDs for segment routing
-		err = nextSID.Set(ctx, 100)
-		if err != nil {
-			log.Warnf("Error initializing atomix counter: %v", err)
-			return nil, err
-		}
-	}
 	sidMap, err := atomixClient.GetMap(ctx, SidMap)
 	if err != nil {
 		log.Warnf("Error creating atomix map: %v", err)
 		return nil, err
 	}
 
-	store := &SIDAtomixStore{
-		nextSID: nextSID,
-		sidMap:  sidMap,
+	store.allocationMap = allocationMap
+	store.sidMap = sidMap
+	entry, err := allocationMap.Get(ctx, lastSIDKey)
+	if err != nil {
+		log.Warnf("Error querying atomix map: %v", err)
+		return nil, err
 	}
+	store.updateRemaining(int64(store.nextSID(bytesToUint32(entry.Value))) - 1)
+
+	log.Infof("SID store allocating from range %d-%d", store.rangeStart, store.rangeEnd)
 
 	return store, nil
 }
 
+// carryOverSidCounter seeds the allocation map with the last SID handed out by the SID counter,
+// unless a replica has already done so, so that SIDs allocated by earlier releases are not reused
+func carryOverSidCounter(ctx context.Context, atomixClient atomix.Client, allocationMap _map.Map) error {
+	sidCounter, err := atomixClient.GetCounter(ctx, SidCounter)
+	if err != nil {
+		log.Warnf("Error creating atomix counter: %v", err)
+		return err
+	}
+	defer func() {
+		_ = sidCounter.Close(ctx)
+	}()
+	lastSID, err := sidCounter.Get(ctx)
+	if err != nil {
+		log.Warnf("Error querying atomix counter: %v", err)
+		return err
+	}
+	if lastSID < 0 {
+		lastSID = 0
+	} else if lastSID > math.MaxUint32 {
+		lastSID = math.MaxUint32
+	}
+
+	_, err = allocationMap.Put(ctx, lastSIDKey, uint32ToBytes(uint32(lastSID)), _map.IfNotSet())
+	if err != nil && !atomixerrors.IsAlreadyExists(err) {
+		log.Warnf("Error initializing atomix map: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SIDEntry is the SID allocated to a switch
+type SIDEntry struct {
+	SwitchID string `json:"switchId"`
+	SID      uint32 `json:"sid"`
+}
+
+// SIDEventType is the type of change made to a SID allocation
+type SIDEventType string
+
+const (
+	// SIDEventReplay is an existing allocation reported at the start of a watch
+	SIDEventReplay SIDEventType = "replay"
+
+	// SIDEventAllocated indicates a SID was allocated to a switch
+	SIDEventAllocated SIDEventType = "allocated"
+
+	// SIDEventUpdated indicates the SID of a switch was changed
+	SIDEventUpdated SIDEventType = "updated"
+
+	// SIDEventReleased indicates a switch no longer owns a SID
+	SIDEventReleased SIDEventType = "released"
+)
+
+// SIDEvent is a change to the SID allocations
+type SIDEvent struct {
+	Type  SIDEventType `json:"type"`
+	Entry SIDEntry     `json:"entry"`
+}
+
 // SIDStore stores UE information
 type SIDStore interface {
 	io.Closer
 
 	// Get a new SID for the given switch
 	Get(ctx context.Context, switchID string) (uint32, error)
+
+	// Lookup returns the SID owned by the given switch without allocating one
+	Lookup(ctx context.Context, switchID string) (uint32, error)
+
+	// List returns all SID allocations, ordered by switch ID
+	List(ctx context.Context) ([]SIDEntry, error)
+
+	// Watch streams allocation changes to ch until ctx is done. If replay is set, the
+	// existing allocations are sent first.
+	Watch(ctx context.Context, ch chan<- SIDEvent, replay bool) error
 }
 
 // SIDAtomixStore is the object implementation of the Store
 type SIDAtomixStore struct {
-	nextSID counter.Counter
-	sidMap  _map.Map
+	allocationMap _map.Map
+	sidMap        _map.Map
+	rangeStart    uint32
+	rangeEnd      uint32
 }
 
 func uint32ToBytes(i uint32) []byte {
@@ -88,6 +192,15 @@ func bytesToUint32(value []byte) uint32 {
 	return binary.LittleEndian.Uint32(value)
 }
 
+// updateRemaining sets the remaining SIDs gauge given the last SID that was handed out
+func (s *SIDAtomixStore) updateRemaining(lastSID int64) {
+	remaining := int64(s.rangeEnd) - lastSID
+	if remaining < 0 {
+		remaining = 0
+	}
+	KpiSIDRemaining.Set(float64(remaining))
+}
+
 // Get gets the SID assigned to the given switch, creating a new one if necessary
 func (s *SIDAtomixStore) Get(ctx context.Context, switchID string) (uint32, error) {
 	if switchID == "" {
@@ -107,14 +220,135 @@ func (s *SIDAtomixStore) Get(ctx context.Context, switchID string) (uint32, erro
 		return 0, err
 	}
 
-	newSid, err := s.nextSID.Increment(ctx, 1)
-	if err == nil {
-		log.Infof("Allocated new SID %d", newSid)
-		sidValue := uint32ToBytes(uint32(newSid))
+	newSid, err := s.reserveSID(ctx, switchID)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Allocated new SID %d", newSid)
+	sidValue := uint32ToBytes(newSid)
+
+	_, err = s.sidMap.Put(ctx, switchID, sidValue)
+	return newSid, err
+}
+
+// nextSID returns the SID to hand out after lastSID, which may be past the end of the range
+func (s *SIDAtomixStore) nextSID(lastSID uint32) uint64 {
+	if lastSID < s.rangeStart {
+		return uint64(s.rangeStart)
+	}
+	return uint64(lastSID) + 1
+}
+
+// reserveSID advances the last SID handed out with compare-and-set, retrying if another replica
+// got there first. An exhausted range leaves the allocation map untouched.
+func (s *SIDAtomixStore) reserveSID(ctx context.Context, switchID string) (uint32, error) {
+	for {
+		entry, err := s.allocationMap.Get(ctx, lastSIDKey)
+		if err != nil {
+			log.Errorf("Error getting from SID allocation map: %v", err)
+			return 0, err
+		}
+		newSid := s.nextSID(bytesToUint32(entry.Value))
+		if newSid > uint64(s.rangeEnd) {
+			s.updateRemaining(int64(s.rangeEnd))
+			return 0, errors.NewUnavailable("SID range %d-%d exhausted, unable to allocate SID for switch %s",
+				s.rangeStart, s.rangeEnd, switchID)
+		}
+
+		_, err = s.allocationMap.Put(ctx, lastSIDKey, uint32ToBytes(uint32(newSid)), _map.IfMatch(entry))
+		if err == nil {
+			s.updateRemaining(int64(newSid))
+			return uint32(newSid), nil
+		}
+		if !atomixerrors.IsConflict(err) {
+			return 0, err
+		}
+		log.Infof("SID %d was allocated by another replica, retrying", newSid)
+	}
+}
+
+// Lookup returns the SID owned by the given switch, or a NotFound error if it has none
+func (s *SIDAtomixStore) Lookup(ctx context.Context, switchID string) (uint32, error) {
+	if switchID == "" {
+		return 0, errors.NewInvalid("ID cannot be empty")
+	}
+
+	entry, err := s.sidMap.Get(ctx, switchID)
+	if err != nil {
+		if atomixerrors.IsNotFound(err) {
+			return 0, errors.NewNotFound("switch %s has no SID", switchID)
+		}
+		return 0, err
+	}
+	return bytesToUint32(entry.Value), nil
+}
+
+// List returns all SID allocations, ordered by switch ID
+func (s *SIDAtomixStore) List(ctx context.Context) ([]SIDEntry, error) {
+	ch := make(chan _map.Entry)
+	err := s.sidMap.Entries(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []SIDEntry{}
+	for entry := range ch {
+		entries = append(entries, SIDEntry{
+			SwitchID: entry.Key,
+			SID:      bytesToUint32(entry.Value),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].SwitchID < entries[j].SwitchID
+	})
+	return entries, nil
+}
+
+// Watch streams allocation changes to ch until ctx is done. ch is closed when the watch ends.
+func (s *SIDAtomixStore) Watch(ctx context.Context, ch chan<- SIDEvent, replay bool) error {
+	var opts []_map.WatchOption
+	if replay {
+		opts = append(opts, _map.WithReplay())
+	}
 
-		_, err = s.sidMap.Put(ctx, switchID, sidValue)
+	mapCh := make(chan _map.Event)
+	err := s.sidMap.Watch(ctx, mapCh, opts...)
+	if err != nil {
+		return err
 	}
-	return uint32(newSid), err
+
+	go func() {
+		defer close(ch)
+		for event := range mapCh {
+			var eventType SIDEventType
+			switch event.Type {
+			case _map.EventReplay:
+				eventType = SIDEventReplay
+			case _map.EventInsert:
+				eventType = SIDEventAllocated
+			case _map.EventUpdate:
+				eventType = SIDEventUpdated
+			case _map.EventRemove:
+				eventType = SIDEventReleased
+			default:
+				continue
+			}
+			sidEvent := SIDEvent{
+				Type: eventType,
+				Entry: SIDEntry{
+					SwitchID: event.Entry.Key,
+					SID:      bytesToUint32(event.Entry.Value),
+				},
+			}
+			// keep draining the map events once the watcher has gone away
+			select {
+			case ch <- sidEvent:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return nil
 }
 
 // Close closes the store
@@ -122,7 +356,7 @@ func (s *SIDAtomixStore) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	err := s.nextSID.Close(ctx)
+	err := s.allocationMap.Close(ctx)
 	if err != nil {
 		return err
 	}
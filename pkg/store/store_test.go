@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/atomix/atomix-go-client/pkg/atomix/test"
+	"github.com/atomix/atomix-go-client/pkg/atomix/test/rsm"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func getAtomixStore(t *testing.T, opts ...Option) (*test.Test, SIDStore) {
+	testAtomix := test.NewTest(
+		rsm.NewProtocol(),
+		test.WithReplicas(1),
+		test.WithPartitions(1))
+	assert.NoError(t, testAtomix.Start())
+
+	client, err := testAtomix.NewClient("node-1")
+	assert.NoError(t, err)
+
+	sidStore, err := NewAtomixStore(context.Background(), client, opts...)
+	assert.NoError(t, err)
+
+	return testAtomix, sidStore
+}
+
+// TestSIDRange makes sure SIDs are allocated from the configured range until it is exhausted
+func TestSIDRange(t *testing.T) {
+	testAtomix, sidStore := getAtomixStore(t, WithSIDRange(16000, 16001))
+	ctx := context.Background()
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(KpiSIDRemaining))
+
+	sid, err := sidStore.Get(ctx, "leaf-one")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(16000), sid)
+
+	sid, err = sidStore.Get(ctx, "leaf-two")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(16001), sid)
+	assert.Equal(t, float64(0), testutil.ToFloat64(KpiSIDRemaining))
+
+	// Existing switches keep their SID once the range is used up
+	sid, err = sidStore.Get(ctx, "leaf-one")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(16000), sid)
+
+	_, err = sidStore.Get(ctx, "spine-one")
+	assert.Error(t, err)
+	assert.True(t, errors.IsUnavailable(err))
+	assert.Contains(t, err.Error(), "16000-16001 exhausted")
+
+	assert.NoError(t, testAtomix.Stop())
+}
+
+// TestConcurrentSIDRange makes sure stores sharing a range never hand out the same SID or more SIDs than it holds
+func TestConcurrentSIDRange(t *testing.T) {
+	testAtomix := test.NewTest(
+		rsm.NewProtocol(),
+		test.WithReplicas(1),
+		test.WithPartitions(1))
+	assert.NoError(t, testAtomix.Start())
+	ctx := context.Background()
+
+	stores := []SIDStore{}
+	for _, node := range []string{"node-1", "node-2"} {
+		client, err := testAtomix.NewClient(node)
+		assert.NoError(t, err)
+		sidStore, err := NewAtomixStore(ctx, client, WithSIDRange(500, 509))
+		assert.NoError(t, err)
+		stores = append(stores, sidStore)
+	}
+
+	var wg sync.WaitGroup
+	sids := make(chan uint32, 40)
+	for i := 0; i < 20; i++ {
+		for n, sidStore := range stores {
+			wg.Add(1)
+			go func(sidStore SIDStore, switchID string) {
+				defer wg.Done()
+				sid, err := sidStore.Get(ctx, switchID)
+				if err == nil {
+					sids <- sid
+				} else {
+					assert.True(t, errors.IsUnavailable(err))
+				}
+			}(sidStore, fmt.Sprintf("switch-%d-%d", n, i))
+		}
+	}
+	wg.Wait()
+	close(sids)
+
+	allocated := []uint32{}
+	for sid := range sids {
+		allocated = append(allocated, sid)
+	}
+	assert.ElementsMatch(t, []uint32{500, 501, 502, 503, 504, 505, 506, 507, 508, 509}, allocated)
+
+	assert.NoError(t, testAtomix.Stop())
+}
+
+// TestSIDCounterCarryOver makes sure the SIDs handed out from the SID counter are not handed out again
+func TestSIDCounterCarryOver(t *testing.T) {
+	testAtomix := test.NewTest(
+		rsm.NewProtocol(),
+		test.WithReplicas(1),
+		test.WithPartitions(1))
+	assert.NoError(t, testAtomix.Start())
+	ctx := context.Background()
+
+	client, err := testAtomix.NewClient("node-1")
+	assert.NoError(t, err)
+	sidCounter, err := client.GetCounter(ctx, SidCounter)
+	assert.NoError(t, err)
+	assert.NoError(t, sidCounter.Set(ctx, 120))
+
+	sidStore, err := NewAtomixStore(ctx, client)
+	assert.NoError(t, err)
+	sid, err := sidStore.Get(ctx, "leaf-one")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(121), sid)
+
+	// only the first store carries the counter over
+	sidStore, err = NewAtomixStore(ctx, client)
+	assert.NoError(t, err)
+	sid, err = sidStore.Get(ctx, "leaf-two")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(122), sid)
+
+	assert.NoError(t, testAtomix.Stop())
+}
+
+// TestInvalidSIDRange makes sure an inverted range is rejected
+func TestInvalidSIDRange(t *testing.T) {
+	testAtomix := test.NewTest(
+		rsm.NewProtocol(),
+		test.WithReplicas(1),
+		test.WithPartitions(1))
+	assert.NoError(t, testAtomix.Start())
+
+	client, err := testAtomix.NewClient("node-1")
+	assert.NoError(t, err)
+
+	_, err = NewAtomixStore(context.Background(), client, WithSIDRange(200, 100))
+	assert.Error(t, err)
+	assert.True(t, errors.IsInvalid(err))
+
+	assert.NoError(t, testAtomix.Stop())
+}
+
+// TestSIDInventory checks the read-only lookup, list and watch operations
+func TestSIDInventory(t *testing.T) {
+	testAtomix, sidStore := getAtomixStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := sidStore.Lookup(ctx, "leaf-one")
+	assert.True(t, errors.IsNotFound(err))
+
+	leafSid, err := sidStore.Get(ctx, "leaf-one")
+	assert.NoError(t, err)
+
+	events := make(chan SIDEvent)
+	assert.NoError(t, sidStore.Watch(ctx, events, true))
+	event := <-events
+	assert.Equal(t, SIDEvent{Type: SIDEventReplay, Entry: SIDEntry{SwitchID: "leaf-one", SID: leafSid}}, event)
+
+	spineSid, err := sidStore.Get(ctx, "spine-one")
+	assert.NoError(t, err)
+	event = <-events
+	assert.Equal(t, SIDEvent{Type: SIDEventAllocated, Entry: SIDEntry{SwitchID: "spine-one", SID: spineSid}}, event)
+
+	sid, err := sidStore.Lookup(ctx, "spine-one")
+	assert.NoError(t, err)
+	assert.Equal(t, spineSid, sid)
+
+	entries, err := sidStore.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []SIDEntry{{SwitchID: "leaf-one", SID: leafSid}, {SwitchID: "spine-one", SID: spineSid}}, entries)
+
+	// Lookup never allocates
+	_, err = sidStore.Lookup(ctx, "leaf-two")
+	assert.True(t, errors.IsNotFound(err))
+	entries, err = sidStore.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	assert.NoError(t, testAtomix.Stop())
+}
@@ -0,0 +1,295 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/onosproject/config-models/models/sdn-fabric-0.1.x/api"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newDhcpServer(ID string, address string) *DhcpServer {
+	return &DhcpServer{
+		DhcpServerId: aStr(ID),
+		Address:      aStr(address),
+	}
+}
+
+// newAppsScope builds a scope for a fabric holding a single leaf switch with one port on cage 2 channel 2
+func newAppsScope() (*FabricScope, *Switch, *Port) {
+	management := &api.OnfSwitch_Switch_Management{
+		Address:    &deviceTestLeafManagementIP,
+		PortNumber: &deviceTestLeafManagementPort,
+	}
+	onfSwitch := newSwitch(&deviceTestLeafID, &deviceTestLeafDisplayName, &deviceTestLeafDescription, management, newAttributes(), RoleLeaf)
+	addPortsAndVlans(onfSwitch)
+	port := onfSwitch.Port[api.OnfSwitch_Switch_Port_Key{CageNumber: 2, ChannelNumber: 2}]
+
+	scope := newScope(&deviceTestFabricID, onfSwitch, &OnosNetConfig{})
+	scope.NetConfig.Apps = map[string]*onosApp{}
+	scope.Fabric = &RootDevice{
+		Switch:     map[string]*Switch{deviceTestLeafID: onfSwitch},
+		DhcpServer: map[string]*DhcpServer{},
+	}
+	return &scope, onfSwitch, port
+}
+
+// TestDhcpRelay tests conversion of a dhcp server to the dhcprelay app config
+func TestDhcpRelay(t *testing.T) {
+	s := Synchronizer{}
+	scope, _, port := newAppsScope()
+
+	server := newDhcpServer("dhcp-one", "11.22.33.10")
+	unreachable := newDhcpServer("dhcp-two", "10.0.0.1")
+	unattached := newDhcpServer("dhcp-three", "11.22.33.11")
+	scope.Fabric.DhcpServer["dhcp-one"] = server
+	scope.Fabric.DhcpServer["dhcp-two"] = unreachable
+	scope.Fabric.DhcpServer["dhcp-three"] = unattached
+	port.DhcpConnectPoint = []string{"dhcp-one", "dhcp-two"}
+
+	assert.NoError(t, s.handleDhcpServer(scope, server))
+	assert.EqualError(t, s.handleDhcpServer(scope, unreachable),
+		"DhcpServer dhcp-two address 10.0.0.1 is not reachable on any vlan subnet of device:leaf-one/201")
+	assert.EqualError(t, s.handleDhcpServer(scope, unattached),
+		"DhcpServer dhcp-three is not the DhcpConnectPoint of any port")
+	assert.Error(t, s.handleDhcpServer(scope, newDhcpServer("dhcp-four", "not-an-ip")))
+
+	dhcpApp, ok := scope.NetConfig.Apps[onosDhcpRelayAppName]
+	assert.True(t, ok)
+	assert.Equal(t, []onosDhcpConfig{{ConnectPoint: "device:leaf-one/201", ServerIps: []string{"11.22.33.10"}}}, dhcpApp.DhcpDefault)
+}
+
+func addAttribute(sw *Switch, key string, value string) {
+	sw.Attribute[key] = &api.OnfSwitch_Switch_Attribute{
+		AttributeKey: aStr(key),
+		Value:        aStr(value),
+	}
+}
+
+// TestStaticHosts tests conversion of host attributes and dhcp servers to static hosts
+func TestStaticHosts(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, port := newAppsScope()
+
+	scope.Fabric.DhcpServer["dhcp-one"] = newDhcpServer("dhcp-one", "11.22.33.10")
+	port.DhcpConnectPoint = []string{"dhcp-one"}
+
+	addAttribute(onfSwitch, "host.upf.mac", "aa:bb:cc:dd:ee:01")
+	addAttribute(onfSwitch, "host.upf.ips", "11.22.33.20,11.22.33.21")
+	addAttribute(onfSwitch, "host.upf.port", "2/2")
+	addAttribute(onfSwitch, "host.upf.vlan", "44")
+	addAttribute(onfSwitch, "host.dhcp-one.mac", "aa:bb:cc:dd:ee:02")
+	addAttribute(onfSwitch, "host.remote.mac", "aa:bb:cc:dd:ee:03")
+	addAttribute(onfSwitch, "host.remote.ips", "10.0.0.1")
+	addAttribute(onfSwitch, "host.remote.port", "2/2")
+
+	// the remote subnet is carried tagged on the port, so an untagged host may not use it
+	onfSwitch.Vlan[66] = newVlan(66, "10.0.0.0/24", "remote")
+	port.Vlans.Tagged = append(port.Vlans.Tagged, 66)
+
+	hosts := lookupAttributeGroups(onfSwitch, hostAttributePrefix)
+	assert.Len(t, hosts, 3)
+	assert.NoError(t, s.handleSwitchHost(scope, "upf", hosts["upf"]))
+	assert.NoError(t, s.handleSwitchHost(scope, "dhcp-one", hosts["dhcp-one"]))
+	assert.EqualError(t, s.handleSwitchHost(scope, "remote", hosts["remote"]),
+		"Switch leaf-one host remote address 10.0.0.1 is not in the subnet of vlan 55 on device:leaf-one/201")
+
+	// a tagged host must use the subnet of its own vlan on a port carrying it
+	hosts["remote"]["vlan"] = "44"
+	assert.EqualError(t, s.handleSwitchHost(scope, "remote", hosts["remote"]),
+		"Switch leaf-one host remote address 10.0.0.1 is not in the subnet of vlan 44 on device:leaf-one/201")
+	onfSwitch.Vlan[77] = newVlan(77, "10.0.1.0/24", "other")
+	hosts["remote"]["vlan"] = "77"
+	assert.EqualError(t, s.handleSwitchHost(scope, "remote", hosts["remote"]),
+		"Switch leaf-one host remote port device:leaf-one/201 does not carry vlan 77")
+	hosts["remote"]["vlan"] = "66"
+	assert.NoError(t, s.handleSwitchHost(scope, "remote", hosts["remote"]))
+
+	assert.Len(t, scope.NetConfig.Hosts, 3)
+	assert.Contains(t, scope.NetConfig.Hosts, "AA:BB:CC:DD:EE:03/66")
+	upf, ok := scope.NetConfig.Hosts["AA:BB:CC:DD:EE:01/44"]
+	assert.True(t, ok)
+	assert.Equal(t, "upf", upf.Basic.Name)
+	assert.Equal(t, []string{"11.22.33.20", "11.22.33.21"}, upf.Basic.Ips)
+	assert.Equal(t, []string{"device:leaf-one/201"}, upf.Basic.Locations)
+
+	dhcp, ok := scope.NetConfig.Hosts["AA:BB:CC:DD:EE:02/None"]
+	assert.True(t, ok)
+	assert.Equal(t, []string{"11.22.33.10"}, dhcp.Basic.Ips)
+	assert.Equal(t, []string{"device:leaf-one/201"}, dhcp.Basic.Locations)
+}
+
+// TestUp4 tests that UP4-enabled leaves are listed in the up4 app config
+func TestUp4(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, _ := newAppsScope()
+
+	// no up4 attribute, no app config
+	assert.NoError(t, s.handleSwitchUp4(scope))
+	assert.NotContains(t, scope.NetConfig.Apps, onosUp4AppName)
+
+	addAttribute(onfSwitch, "up4", "true")
+	assert.EqualError(t, s.handleSwitchUp4(scope),
+		"fabric fabric-one switch leaf-one has up4 enabled: pipeconf pipe.configuration is not a UPF-capable fabric-tna profile")
+
+	delete(onfSwitch.Attribute, "pipeconf")
+	assert.EqualError(t, s.handleSwitchUp4(scope), "fabric fabric-one switch leaf-one has up4 enabled but no pipeconf attribute")
+
+	addAttribute(onfSwitch, "pipeconf", "org.stratumproject.fabric-upf-int.stratum_bfrt.mavericks_sde_9_7_0")
+	assert.NoError(t, s.handleSwitchUp4(scope))
+
+	onfSwitch.Role = RoleSpine
+	assert.EqualError(t, s.handleSwitchUp4(scope), "fabric fabric-one switch leaf-one has up4 enabled but is not a leaf")
+
+	up4App, ok := scope.NetConfig.Apps[onosUp4AppName]
+	assert.True(t, ok)
+	assert.Equal(t, []string{"device:leaf-one"}, up4App.Up4.Devices)
+
+	assert.NoError(t, validateUp4Pipeconf("org.stratumproject.fabric-upf.montara_sde_9_7_0"))
+	assert.Error(t, validateUp4Pipeconf("org.stratumproject.fabric-int.montara_sde_9_7_0"))
+}
+
+// TestTelemetry tests conversion of int attributes to the INT report config
+func TestTelemetry(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, _ := newAppsScope()
+
+	assert.NoError(t, s.handleSwitchTelemetry(scope))
+	assert.NotContains(t, scope.NetConfig.Apps, onosIntAppName)
+
+	addAttribute(onfSwitch, "int.collector-ip", "10.32.11.2")
+	addAttribute(onfSwitch, "int.watch-subnets", "10.32.11.0/24,10.33.0.0/16")
+	addAttribute(onfSwitch, "int.queue.0.trigger-ns", "2000")
+	addAttribute(onfSwitch, "int.queue.0.reset-ns", "500")
+	addAttribute(onfSwitch, "int.queue.2.trigger-ns", "1000")
+	assert.NoError(t, s.handleSwitchTelemetry(scope))
+
+	intApp, ok := scope.NetConfig.Apps[onosIntAppName]
+	assert.True(t, ok)
+	assert.Equal(t, &onosTelemetryReport{
+		CollectorIP:               "10.32.11.2",
+		CollectorPort:             defaultTelemetryCollectorPort,
+		MinFlowHopLatencyChangeNs: defaultTelemetryMinFlowHopLatencyChangeNs,
+		WatchSubnets:              []string{"10.32.11.0/24", "10.33.0.0/16"},
+		QueueReportLatencyThresholds: map[string]onosTelemetryQueue{
+			"0": {TriggerNs: 2000, ResetNs: 500},
+			"2": {TriggerNs: 1000},
+		},
+	}, intApp.TelemetryReport)
+
+	// the same settings on a second switch are accepted, different ones are not
+	assert.NoError(t, s.handleSwitchTelemetry(scope))
+	addAttribute(onfSwitch, "int.collector-port", "1234")
+	assert.EqualError(t, s.handleSwitchTelemetry(scope),
+		"fabric fabric-one switch leaf-one INT settings conflict with those of another switch")
+
+	addAttribute(onfSwitch, "int.queue.40.trigger-ns", "1000")
+	assert.EqualError(t, s.handleSwitchTelemetry(scope), "fabric fabric-one switch leaf-one has invalid INT queue 40")
+}
+
+// TestComponentConfig tests that component attributes are collected and pushed per component
+func TestComponentConfig(t *testing.T) {
+	const hostProbing = "org.onosproject.provider.hostprobing.impl.DefaultHostProbingProvider"
+
+	posts := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posts[r.URL.Path] = string(body)
+	}))
+	defer ts.Close()
+
+	s := Synchronizer{cache: map[string]interface{}{}, partialUpdateEnable: true}
+	scope, onfSwitch, _ := newAppsScope()
+	scope.OnosEndpoint = aStr(ts.URL + "/")
+	scope.OnosUsername = aStr("onos")
+	scope.OnosPassword = aStr("rocks")
+
+	addAttribute(onfSwitch, "component."+hostProbing+".monitorHosts", "true")
+	addAttribute(onfSwitch, "component."+hostProbing+".probeRate", "30000")
+	assert.NoError(t, s.handleSwitchComponentConfig(scope))
+	assert.Equal(t, OnosComponentConfig{"monitorHosts": "true", "probeRate": "30000"}, scope.ComponentConfig[hostProbing])
+
+	pushFailures, err := s.SynchronizeComponentConfigToOnos(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pushFailures)
+	assert.JSONEq(t, `{"monitorHosts": "true", "probeRate": "30000"}`, posts["/onos/v1/configuration/"+hostProbing])
+
+	// unchanged config is not pushed again
+	posts = map[string]string{}
+	pushFailures, err = s.SynchronizeComponentConfigToOnos(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pushFailures)
+	assert.Empty(t, posts)
+
+	// only the conflicting property is skipped
+	addAttribute(onfSwitch, "component."+hostProbing+".probeRate", "1000")
+	addAttribute(onfSwitch, "component."+hostProbing+".timeout", "5")
+	addAttribute(onfSwitch, "component.org.onosproject.other.enabled", "false")
+	assert.EqualError(t, s.handleSwitchComponentConfig(scope), "fabric fabric-one switch leaf-one sets component "+
+		hostProbing+" property probeRate to 1000, conflicting with 30000")
+	assert.Equal(t, OnosComponentConfig{"monitorHosts": "true", "probeRate": "30000", "timeout": "5"}, scope.ComponentConfig[hostProbing])
+	assert.Equal(t, OnosComponentConfig{"enabled": "false"}, scope.ComponentConfig["org.onosproject.other"])
+}
+
+func newRoute(ID string, prefix string, address string, metric uint8) *Route {
+	return &Route{
+		RouteId: aStr(ID),
+		Prefix:  aStr(prefix),
+		Address: aStr(address),
+		Metric:  aUint8(metric),
+	}
+}
+
+// TestRoutes tests grouping of routes by prefix and selection of the lowest metric next hops
+func TestRoutes(t *testing.T) {
+	s := Synchronizer{}
+	scope, _, _ := newAppsScope()
+	scope.Fabric.Route = map[string]*Route{
+		"up-1":   newRoute("up-1", "10.0.0.0/8", "192.168.1.1", 10),
+		"up-2":   newRoute("up-2", "10.1.2.3/8", "192.168.1.2", 10),
+		"backup": newRoute("backup", "10.0.0.0/8", "192.168.1.3", 20),
+		"v6":     newRoute("v6", "2001:db8::/32", "2001:db8::1", 1),
+		"mixed":  newRoute("mixed", "2001:db8::/32", "192.168.1.1", 0),
+	}
+
+	s.handleRoutes(scope)
+
+	routeApp, ok := scope.NetConfig.Apps[onosRouteAppName]
+	assert.True(t, ok)
+	assert.Equal(t, []onosRoute{
+		{Prefix: "10.0.0.0/8", NextHop: "192.168.1.1"},
+		{Prefix: "10.0.0.0/8", NextHop: "192.168.1.2"},
+		{Prefix: "2001:db8::/32", NextHop: "2001:db8::1"},
+	}, routeApp.Routes)
+
+	assert.EqualError(t, validateRoute(scope.Fabric.Route["mixed"]),
+		"Route mixed Prefix 2001:db8::/32 and Address 192.168.1.1 are of different IP versions")
+}
+
+// TestXconnect tests conversion of xconnect attributes to the segmentrouting xconnect config
+func TestXconnect(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, _ := newAppsScope()
+	port := addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 3}, 3, 0, "port3", "Port 3", api.OnfSdnFabricTypes_Speed_speed_10g)
+	port.Vlans = &api.OnfSwitch_Switch_Port_Vlans{Tagged: []uint16{44}}
+
+	assert.NoError(t, s.handleSwitchXconnect(scope, "edge", map[string]string{"vlan": "44", "ports": "2/2,3/0"}))
+	srApp, ok := scope.NetConfig.Apps[onosSegmentRoutingApp]
+	assert.True(t, ok)
+	assert.Equal(t, []onosXconnect{{Name: "edge", Vlan: 44, Ports: []uint32{201, 3}}}, srApp.Xconnect["device:"+deviceTestLeafID])
+
+	// port 3/0 does not carry vlan 55
+	assert.Error(t, s.handleSwitchXconnect(scope, "bad-vlan", map[string]string{"vlan": "55", "ports": "2/2,3/0"}))
+	// vlan 66 is not on the switch
+	assert.Error(t, s.handleSwitchXconnect(scope, "no-vlan", map[string]string{"vlan": "66", "ports": "2/2,3/0"}))
+	assert.Error(t, s.handleSwitchXconnect(scope, "one-port", map[string]string{"vlan": "44", "ports": "2/2"}))
+	assert.Error(t, s.handleSwitchXconnect(scope, "same-port", map[string]string{"vlan": "44", "ports": "2/2,2/2"}))
+	assert.Error(t, s.handleSwitchXconnect(scope, "no-port", map[string]string{"vlan": "44", "ports": "2/2,4/0"}))
+	assert.Len(t, srApp.Xconnect["device:"+deviceTestLeafID], 1)
+}
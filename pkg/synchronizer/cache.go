@@ -15,6 +15,9 @@ import (
 const (
 	// CacheModelNetConfig is the modelName to use when caching fabric to the onos
 	CacheModelNetConfig = "netconfig"
+
+	// CacheModelComponentConfig is the modelName to use when caching onos component configuration
+	CacheModelComponentConfig = "componentconfig"
 )
 
 // CacheCheck returns true if (modelName, modelId) exists in the cache and the contents have not
@@ -10,6 +10,7 @@ import (
 	"context"
 	"github.com/onosproject/fabric-adapter/pkg/store"
 	"github.com/onosproject/fabric-adapter/pkg/stratum_hal"
+	"sync"
 	"time"
 
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
@@ -25,6 +26,12 @@ const (
 
 	// DefaultPartialUpdateEnable is the default partial update setting
 	DefaultPartialUpdateEnable = true
+
+	// DefaultAppActivateEnable is the default setting for activating missing ONOS applications
+	DefaultAppActivateEnable = true
+
+	// DefaultStratumVerifyEnable is the default setting for reading back the chassis config after a push
+	DefaultStratumVerifyEnable = false
 )
 
 // Synchronizer is a Version 3 synchronizer.
@@ -34,10 +41,14 @@ type Synchronizer struct {
 	updateChannel       chan *ConfigUpdate
 	retryInterval       time.Duration
 	partialUpdateEnable bool
+	appActivateEnable   bool
+	stratumVerifyEnable bool
 	caPath              string
 	keyPath             string
 	certPath            string
 	topoEndpoint        string
+	sidRangeStart       uint32
+	sidRangeEnd         uint32
 
 	// Busy indicator, primarily used for unit testing. The channel length in and of itself
 	// is not sufficient, as it does not include the potential update that is currently syncing.
@@ -57,6 +68,16 @@ type Synchronizer struct {
 	kafkaErrorChannel chan error
 
 	sidStore store.SIDStore
+
+	// ONOS cluster member that served the last push, for each fabric
+	activeControllers map[string]string
+
+	// drivers and pipeconfs offered by each ONOS endpoint
+	onosCatalogs map[string]*onosCatalog
+
+	// gNMI capabilities of each switch, by switch ID
+	switchCapabilities     map[string]*SwitchCapabilities
+	switchCapabilitiesLock sync.Mutex
 }
 
 // ConfigUpdate holds the configuration for a particular synchronization request
@@ -83,5 +104,6 @@ type FabricScope struct {
 	StratumEndpoint      *string      // Endpoint of Fabric to post to
 	SecureTransport      bool         // Is the current switch using secure transport
 	NetConfig            *OnosNetConfig
+	ComponentConfig      map[string]OnosComponentConfig // Onos component properties, keyed by component name
 	StratumChassisConfig stratum_hal.ChassisConfig
 }
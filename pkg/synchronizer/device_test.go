@@ -7,6 +7,7 @@ package synchronizer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/atomix/atomix-go-client/pkg/atomix/test"
 	"github.com/atomix/atomix-go-client/pkg/atomix/test/rsm"
@@ -248,7 +249,7 @@ func TestDeviceToSegmentRouting(t *testing.T) {
 	// Check the segment routing data
 	checkSegmentRoutingDevice(t, netconfDevice, 101, deviceTestLeafManagementIP, true)
 
-	port, ok := scope.NetConfig.Ports["device:leaf-one/202"]
+	port, ok := scope.NetConfig.Ports["device:leaf-one/201"]
 	assert.True(t, ok)
 	expectedSubnets := []string{"11.22.33.55/24", "11.22.33.44/24"}
 
@@ -318,3 +319,145 @@ func TestSIDDUniqueness(t *testing.T) {
 
 	assert.NoError(t, testAtomix.Stop())
 }
+
+// TestGridLayout tests the default GUI layout and its attribute overrides
+func TestGridLayout(t *testing.T) {
+	fabric := &RootDevice{Switch: map[string]*Switch{}}
+	for _, id := range []string{"spine-1", "spine-2"} {
+		fabric.Switch[id] = newSwitch(aStr(id), aStr(id), aStr(id), nil, newAttributes(), RoleSpine)
+	}
+	for _, id := range []string{"leaf-1", "leaf-2", "leaf-3", "leaf-4"} {
+		fabric.Switch[id] = newSwitch(aStr(id), aStr(id), aStr(id), nil, newAttributes(), RoleLeaf)
+	}
+	fabric.Switch["leaf-1"].SwitchPair = &api.OnfSwitch_Switch_SwitchPair{PairedSwitch: aStr("leaf-3")}
+	fabric.Switch["leaf-3"].SwitchPair = &api.OnfSwitch_Switch_SwitchPair{PairedSwitch: aStr("leaf-1")}
+
+	layout := computeGridLayout(fabric)
+	assert.Equal(t, map[string]gridLocation{
+		"spine-1": {X: 200, Y: gridSpineRow},
+		"spine-2": {X: 300, Y: gridSpineRow},
+		"leaf-1":  {X: 100, Y: gridLeafRow},
+		"leaf-3":  {X: 200, Y: gridLeafRow},
+		"leaf-2":  {X: 300, Y: gridLeafRow},
+		"leaf-4":  {X: 400, Y: gridLeafRow},
+	}, layout)
+
+	s := Synchronizer{}
+	scope := &FabricScope{FabricId: &deviceTestFabricID, Fabric: fabric, Switch: fabric.Switch["leaf-3"]}
+	device := &onosDevice{}
+	s.handleSwitchLocation(scope, device)
+	assert.Equal(t, onosLocTypeGrid, device.Basic.LocType)
+	assert.Equal(t, uint16(200), *device.Basic.GridX)
+	assert.Equal(t, uint16(gridLeafRow), *device.Basic.GridY)
+
+	scope.Switch.Attribute["grid-y"] = &api.OnfSwitch_Switch_Attribute{AttributeKey: aStr("grid-y"), Value: aStr("350")}
+	s.handleSwitchLocation(scope, device)
+	assert.Equal(t, uint16(200), *device.Basic.GridX)
+	assert.Equal(t, uint16(350), *device.Basic.GridY)
+
+	// an invalid coordinate keeps the computed one
+	scope.Switch.Attribute["grid-x"] = &api.OnfSwitch_Switch_Attribute{AttributeKey: aStr("grid-x"), Value: aStr("left")}
+	device = &onosDevice{}
+	s.handleSwitchLocation(scope, device)
+	assert.Equal(t, uint16(200), *device.Basic.GridX)
+	assert.Equal(t, uint16(350), *device.Basic.GridY)
+
+	// a device can be placed at 0
+	scope.Switch.Attribute["grid-x"] = &api.OnfSwitch_Switch_Attribute{AttributeKey: aStr("grid-x"), Value: aStr("0")}
+	device = &onosDevice{}
+	s.handleSwitchLocation(scope, device)
+	assert.Equal(t, uint16(0), *device.Basic.GridX)
+	data, err := json.Marshal(device.Basic)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"gridX":0`)
+
+	// a switch outside the computed layout without overrides has no coordinates
+	scope.Switch = newSwitch(aStr("other"), aStr("other"), aStr("other"), nil, newAttributes(), RoleLeaf)
+	device = &onosDevice{}
+	s.handleSwitchLocation(scope, device)
+	data, err = json.Marshal(device.Basic)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "gridX")
+	assert.NotContains(t, string(data), "gridY")
+}
+
+func newPairedLeaf(ID string, peerID string, address string) *Switch {
+	management := &api.OnfSwitch_Switch_Management{
+		Address:    aStr(address),
+		PortNumber: aUint16(9339),
+	}
+	leaf := newSwitch(aStr(ID), aStr(ID), aStr(ID), management, newAttributes(), RoleLeaf)
+	addPortsAndVlans(leaf)
+	leaf.SwitchPair = &api.OnfSwitch_Switch_SwitchPair{
+		PairedSwitch: aStr(peerID),
+		PairingPort:  map[api.OnfSwitch_Switch_SwitchPair_PairingPort_Key]*api.OnfSwitch_Switch_SwitchPair_PairingPort{},
+	}
+	for _, cage := range []uint8{5, 3} {
+		addNewPort(leaf, api.OnfSwitch_Switch_Port_Key{CageNumber: cage}, cage, 0, "pairing", "pairing", api.OnfSdnFabricTypes_Speed_speed_100g)
+		leaf.SwitchPair.PairingPort[api.OnfSwitch_Switch_SwitchPair_PairingPort_Key{CageNumber: cage}] =
+			&api.OnfSwitch_Switch_SwitchPair_PairingPort{CageNumber: aUint8(cage), ChannelNumber: aUint8(0)}
+	}
+	return leaf
+}
+
+// TestLeafPair tests rendering and validation of paired leaves
+func TestLeafPair(t *testing.T) {
+	testAtomix, sidStore := getAtomixStore(t)
+	s := Synchronizer{sidStore: sidStore}
+
+	leaf1 := newPairedLeaf("leaf-1", "leaf-2", "10.0.0.1")
+	leaf2 := newPairedLeaf("leaf-2", "leaf-1", "10.0.0.2")
+	scope := newScope(&deviceTestFabricID, leaf1, &OnosNetConfig{})
+	scope.Fabric = &RootDevice{Switch: map[string]*Switch{"leaf-1": leaf1, "leaf-2": leaf2}}
+
+	// several pairing ports must form a trunk
+	assert.NoError(t, s.handleSwitch(context.Background(), &scope))
+	assert.Empty(t, scope.NetConfig.Devices["device:leaf-1"].SegmentRouting.PairDeviceID)
+	_, err := lookupPairLocalPort(leaf1, scope.SwitchModel)
+	assert.EqualError(t, err, "Switch leaf-1 has 2 pairing ports, which must be the members of one trunk")
+	addAttribute(leaf1, "trunk.1000.members", "5/0")
+	_, err = lookupPairLocalPort(leaf1, scope.SwitchModel)
+	assert.Error(t, err)
+	addAttribute(leaf1, "trunk.1001.members", "3/0,5/0")
+
+	assert.NoError(t, s.handleSwitch(context.Background(), &scope))
+	device := scope.NetConfig.Devices["device:leaf-1"]
+	assert.Equal(t, "device:leaf-2", device.SegmentRouting.PairDeviceID)
+	assert.Equal(t, uint32(1001), device.SegmentRouting.PairLocalPort)
+
+	// a single pairing port is the pair link
+	delete(leaf1.SwitchPair.PairingPort, api.OnfSwitch_Switch_SwitchPair_PairingPort_Key{CageNumber: 5})
+	pairLocalPort, err := lookupPairLocalPort(leaf1, scope.SwitchModel)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), pairLocalPort)
+	leaf1.SwitchPair.PairingPort[api.OnfSwitch_Switch_SwitchPair_PairingPort_Key{CageNumber: 5}] =
+		&api.OnfSwitch_Switch_SwitchPair_PairingPort{CageNumber: aUint8(5), ChannelNumber: aUint8(0)}
+
+	peer, err := validateSwitchPair(scope.Fabric, leaf2)
+	assert.NoError(t, err)
+	assert.Equal(t, leaf1, peer)
+
+	// pairing ports must match on both sides
+	delete(leaf2.SwitchPair.PairingPort, api.OnfSwitch_Switch_SwitchPair_PairingPort_Key{CageNumber: 5})
+	_, err = validateSwitchPair(scope.Fabric, leaf1)
+	assert.EqualError(t, err, "Switch leaf-1 pairing ports do not match those of paired switch leaf-2")
+	leaf2.SwitchPair.PairingPort[api.OnfSwitch_Switch_SwitchPair_PairingPort_Key{CageNumber: 5}] =
+		&api.OnfSwitch_Switch_SwitchPair_PairingPort{CageNumber: aUint8(5), ChannelNumber: aUint8(0)}
+
+	// vlans must match on both sides
+	leaf2.Vlan[44].Subnet = []string{"11.22.99.0/24"}
+	_, err = validateSwitchPair(scope.Fabric, leaf1)
+	assert.EqualError(t, err, "Switch leaf-1 vlans do not match those of paired switch leaf-2")
+
+	// the peer must point back
+	leaf2.SwitchPair.PairedSwitch = aStr("leaf-3")
+	_, err = validateSwitchPair(scope.Fabric, leaf1)
+	assert.EqualError(t, err, "Switch leaf-1 paired switch leaf-2 is not paired back with it")
+
+	// an invalid pair leaves the device unpaired
+	scope.NetConfig.Devices = map[string]*onosDevice{}
+	assert.NoError(t, s.handleSwitch(context.Background(), &scope))
+	assert.Empty(t, scope.NetConfig.Devices["device:leaf-1"].SegmentRouting.PairDeviceID)
+
+	assert.NoError(t, testAtomix.Stop())
+}
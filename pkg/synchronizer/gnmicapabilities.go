@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// gNMI capability negotiation: switches differ in the encodings they accept, so each switch is
+// asked for its capabilities once per connection and the chassis config is pushed in an encoding
+// it supports.
+
+package synchronizer
+
+import (
+	"context"
+	"fmt"
+	gnmiapi "github.com/openconfig/gnmi/proto/gnmi"
+	"sort"
+	"strings"
+	"time"
+)
+
+// chassisConfigEncodings are the encodings a chassis config can be pushed in, most preferred first.
+// BYTES and ASCII carry the text form of the config, PROTO the binary form.
+var chassisConfigEncodings = []gnmiapi.Encoding{gnmiapi.Encoding_BYTES, gnmiapi.Encoding_PROTO, gnmiapi.Encoding_ASCII}
+
+// chassisConfigPath is the path negotiated for the chassis config of a switch that supports one of
+// the chassis config encodings. Stratum replaces the whole config at the root, whatever models it
+// reports, so the root is the only path it is offered.
+const chassisConfigPath = ""
+
+// SwitchCapabilityModel is a model a switch supports
+type SwitchCapabilityModel struct {
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+	Version      string `json:"version"`
+}
+
+// SwitchCapabilities is the gNMI inventory of a switch, as reported by its Capabilities RPC, and the
+// encoding and path chosen for its chassis config. Encoding is empty if the switch was refused.
+type SwitchCapabilities struct {
+	SwitchID    string                  `json:"switchId"`
+	Endpoint    string                  `json:"endpoint"`
+	GnmiVersion string                  `json:"gnmiVersion"`
+	Encodings   []string                `json:"encodings"`
+	Models      []SwitchCapabilityModel `json:"models"`
+	Encoding    string                  `json:"encoding"`
+	Path        string                  `json:"path"`
+	Fetched     time.Time               `json:"fetched"`
+
+	// connected is cleared when a push fails, so that the capabilities are asked for again
+	connected bool
+}
+
+// chooseChassisConfigEncoding picks the most preferred chassis config encoding that a switch supports
+func chooseChassisConfigEncoding(supported []gnmiapi.Encoding) (gnmiapi.Encoding, bool) {
+	for _, encoding := range chassisConfigEncodings {
+		for _, s := range supported {
+			if s == encoding {
+				return encoding, true
+			}
+		}
+	}
+	return gnmiapi.Encoding_JSON, false
+}
+
+// lookupSwitchCapabilities returns the capabilities of a switch, asking the switch for them if they
+// were not negotiated on its current endpoint yet. A switch that supports none of the chassis config
+// encodings is recorded in the inventory and refused.
+func (s *Synchronizer) lookupSwitchCapabilities(scope *FabricScope, endpoint string, client Client) (*SwitchCapabilities, error) {
+	switchID := *scope.Switch.SwitchId
+
+	s.switchCapabilitiesLock.Lock()
+	capabilities, okay := s.switchCapabilities[switchID]
+	connected := okay && capabilities.connected && capabilities.Endpoint == endpoint
+	s.switchCapabilitiesLock.Unlock()
+	if connected {
+		return capabilities, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	response, err := client.Capabilities(ctx, &gnmiapi.CapabilityRequest{})
+	if err != nil {
+		return nil, &PushError{
+			Endpoint:   endpoint,
+			StatusCode: 500,
+			Status:     err.Error(),
+			Operation:  "CAPABILITIES",
+		}
+	}
+
+	capabilities = &SwitchCapabilities{
+		SwitchID:    switchID,
+		Endpoint:    endpoint,
+		GnmiVersion: response.GetGNMIVersion(),
+		Encodings:   []string{},
+		Models:      []SwitchCapabilityModel{},
+		Fetched:     time.Now(),
+	}
+	for _, encoding := range response.GetSupportedEncodings() {
+		capabilities.Encodings = append(capabilities.Encodings, encoding.String())
+	}
+	for _, model := range response.GetSupportedModels() {
+		capabilities.Models = append(capabilities.Models, SwitchCapabilityModel{
+			Name:         model.GetName(),
+			Organization: model.GetOrganization(),
+			Version:      model.GetVersion(),
+		})
+	}
+	sort.Slice(capabilities.Models, func(i, j int) bool { return capabilities.Models[i].Name < capabilities.Models[j].Name })
+
+	encoding, supported := chooseChassisConfigEncoding(response.GetSupportedEncodings())
+	if supported {
+		capabilities.Encoding = encoding.String()
+		capabilities.Path = chassisConfigPath
+		capabilities.connected = true
+	}
+	s.recordSwitchCapabilities(scope, capabilities)
+	log.Infof("Switch %s at %s supports gNMI %s, encodings %v and %d models", switchID, endpoint,
+		capabilities.GnmiVersion, capabilities.Encodings, len(capabilities.Models))
+
+	if !supported {
+		names := []string{}
+		for _, e := range chassisConfigEncodings {
+			names = append(names, e.String())
+		}
+		return nil, fmt.Errorf("Switch %s supports none of the chassis config encodings %s, only [%s]",
+			switchID, strings.Join(names, ", "), strings.Join(capabilities.Encodings, ", "))
+	}
+	return capabilities, nil
+}
+
+// recordSwitchCapabilities adds the capabilities of a switch to the inventory
+func (s *Synchronizer) recordSwitchCapabilities(scope *FabricScope, capabilities *SwitchCapabilities) {
+	s.switchCapabilitiesLock.Lock()
+	defer s.switchCapabilitiesLock.Unlock()
+	if s.switchCapabilities == nil {
+		s.switchCapabilities = map[string]*SwitchCapabilities{}
+	}
+	if previous, okay := s.switchCapabilities[capabilities.SwitchID]; okay {
+		KpiSwitchGnmiCapabilities.DeleteLabelValues(*scope.FabricId, previous.SwitchID, previous.GnmiVersion, previous.Encoding)
+	}
+	s.switchCapabilities[capabilities.SwitchID] = capabilities
+	KpiSwitchGnmiCapabilities.WithLabelValues(*scope.FabricId, capabilities.SwitchID, capabilities.GnmiVersion, capabilities.Encoding).Set(1)
+}
+
+// forgetSwitchConnection drops the negotiated encoding of a switch after a failed push, so that the
+// capabilities are asked for again on the next connection. The switch stays in the inventory.
+func (s *Synchronizer) forgetSwitchConnection(switchID string) {
+	s.switchCapabilitiesLock.Lock()
+	defer s.switchCapabilitiesLock.Unlock()
+	if capabilities, okay := s.switchCapabilities[switchID]; okay {
+		capabilities.connected = false
+	}
+}
+
+// GetSwitchCapabilities returns the gNMI capabilities of every switch that was asked for them, by switch ID
+func (s *Synchronizer) GetSwitchCapabilities() map[string]SwitchCapabilities {
+	s.switchCapabilitiesLock.Lock()
+	defer s.switchCapabilitiesLock.Unlock()
+	inventory := map[string]SwitchCapabilities{}
+	for switchID, capabilities := range s.switchCapabilities {
+		inventory[switchID] = *capabilities
+	}
+	return inventory
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/onosproject/fabric-adapter/pkg/stratum_hal"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChooseChassisConfigEncoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		supported []gpb.Encoding
+		encoding  gpb.Encoding
+		okay      bool
+	}{
+		{name: "bytes preferred", supported: []gpb.Encoding{gpb.Encoding_PROTO, gpb.Encoding_BYTES}, encoding: gpb.Encoding_BYTES, okay: true},
+		{name: "proto", supported: []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_PROTO}, encoding: gpb.Encoding_PROTO, okay: true},
+		{name: "ascii", supported: []gpb.Encoding{gpb.Encoding_ASCII}, encoding: gpb.Encoding_ASCII, okay: true},
+		{name: "json only", supported: []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF}, okay: false},
+		{name: "none", supported: nil, okay: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoding, okay := chooseChassisConfigEncoding(test.supported)
+			assert.Equal(t, test.okay, okay)
+			if test.okay {
+				assert.Equal(t, test.encoding, encoding)
+			}
+		})
+	}
+}
+
+// TestSwitchCapabilities tests that capabilities are asked for once per connection and kept as inventory
+func TestSwitchCapabilities(t *testing.T) {
+	s := Synchronizer{}
+	scope, _, _ := newAppsScope()
+	tc := &testClient{expectedStatus: http.StatusOK, capabilities: &gpb.CapabilityResponse{
+		GNMIVersion:        "0.7.0",
+		SupportedEncodings: []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_PROTO},
+		SupportedModels: []*gpb.ModelData{
+			{Name: "openconfig-platform", Organization: "OpenConfig working group", Version: "0.13.0"},
+			{Name: "openconfig-interfaces", Organization: "OpenConfig working group", Version: "2.4.3"},
+		},
+	}}
+
+	capabilities, err := s.lookupSwitchCapabilities(scope, "leaf:9339", tc)
+	assert.NoError(t, err)
+	assert.Equal(t, "PROTO", capabilities.Encoding)
+	_, err = s.lookupSwitchCapabilities(scope, "leaf:9339", tc)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tc.capabilityRequests)
+
+	inventory := s.GetSwitchCapabilities()
+	assert.Len(t, inventory, 1)
+	leaf := inventory[deviceTestLeafID]
+	assert.Equal(t, "leaf:9339", leaf.Endpoint)
+	assert.Equal(t, "0.7.0", leaf.GnmiVersion)
+	assert.Equal(t, []string{"JSON", "PROTO"}, leaf.Encodings)
+	assert.Equal(t, []SwitchCapabilityModel{
+		{Name: "openconfig-interfaces", Organization: "OpenConfig working group", Version: "2.4.3"},
+		{Name: "openconfig-platform", Organization: "OpenConfig working group", Version: "0.13.0"},
+	}, leaf.Models)
+
+	// a new endpoint or a failed push is a new connection
+	_, err = s.lookupSwitchCapabilities(scope, "leaf:9559", tc)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tc.capabilityRequests)
+	s.forgetSwitchConnection(deviceTestLeafID)
+	_, err = s.lookupSwitchCapabilities(scope, "leaf:9559", tc)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, tc.capabilityRequests)
+
+	// a switch without a usable encoding is refused, asked again next time, and kept in the inventory
+	tc.capabilities = &gpb.CapabilityResponse{GNMIVersion: "0.8.0", SupportedEncodings: []gpb.Encoding{gpb.Encoding_JSON_IETF}}
+	s.forgetSwitchConnection(deviceTestLeafID)
+	_, err = s.lookupSwitchCapabilities(scope, "leaf:9559", tc)
+	assert.EqualError(t, err, "Switch leaf-one supports none of the chassis config encodings BYTES, PROTO, ASCII, only [JSON_IETF]")
+	_, err = s.lookupSwitchCapabilities(scope, "leaf:9559", tc)
+	assert.Error(t, err)
+	assert.Equal(t, 5, tc.capabilityRequests)
+	assert.Equal(t, "0.8.0", s.GetSwitchCapabilities()[deviceTestLeafID].GnmiVersion)
+	assert.Equal(t, "", s.GetSwitchCapabilities()[deviceTestLeafID].Encoding)
+
+	tc.expectedStatus = http.StatusServiceUnavailable
+	_, err = s.lookupSwitchCapabilities(scope, "leaf:9559", tc)
+	assert.Equal(t, "CAPABILITIES", err.(*PushError).Operation)
+}
+
+// TestStratumCapabilities tests that the chassis config is pushed in the encoding the switch supports
+func TestStratumCapabilities(t *testing.T) {
+	tc := &testClient{expectedStatus: http.StatusOK}
+	GnmiPushClientFactory = func(dest string, target string, secure bool) Client { return tc }
+	defer func() { GnmiPushClientFactory = newClient }()
+
+	s := NewSynchronizer(WithStratumVerifyEnable(true))
+	scope, onfSwitch, _ := newAppsScope()
+	onfSwitch.ModelId = scope.SwitchModel.SwitchModelId
+	scope.Fabric.SwitchModel = map[string]*SwitchModel{*scope.SwitchModel.SwitchModelId: scope.SwitchModel}
+
+	tc.capabilities = &gpb.CapabilityResponse{GNMIVersion: "0.7.0", SupportedEncodings: []gpb.Encoding{gpb.Encoding_PROTO}}
+	failures, err := s.SynchronizeFabricToStratum(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failures)
+	pushed := &stratum_hal.ChassisConfig{}
+	assert.NoError(t, proto.Unmarshal(tc.setVal.GetProtoBytes(), pushed))
+	assert.True(t, proto.Equal(&scope.StratumChassisConfig, pushed))
+
+	// the config is pushed to and read back from the negotiated path
+	assert.Empty(t, tc.getRequest.Path[0].Elem)
+	s.switchCapabilities[deviceTestLeafID].Path = "chassis"
+	_, err = s.SynchronizeFabricToStratum(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, "chassis", tc.getRequest.Path[0].Elem[0].Name)
+
+	// a refused switch is skipped and counted, but not retried
+	refused := testutil.ToFloat64(KpiStratumRefusedTotal.WithLabelValues(deviceTestFabricID, deviceTestLeafID))
+	tc.capabilities = &gpb.CapabilityResponse{GNMIVersion: "0.7.0", SupportedEncodings: []gpb.Encoding{gpb.Encoding_JSON}}
+	s.forgetSwitchConnection(deviceTestLeafID)
+	tc.setVal = nil
+	failures, err = s.SynchronizeFabricToStratum(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failures)
+	assert.Nil(t, tc.setVal)
+	assert.Equal(t, refused+1, testutil.ToFloat64(KpiStratumRefusedTotal.WithLabelValues(deviceTestFabricID, deviceTestLeafID)))
+
+	// an unreachable switch is skipped and retried
+	tc.expectedStatus = http.StatusServiceUnavailable
+	failures, err = s.SynchronizeFabricToStratum(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures)
+	assert.Nil(t, tc.setVal)
+}
@@ -106,14 +106,17 @@ func (c *client) Poll() error {
 
 // Capabilities returns the capabilities of the target
 func (c *client) Capabilities(ctx context.Context, req *gpb.CapabilityRequest) (*gpb.CapabilityResponse, error) {
+	c.client = c.getGNMIClient(ctx)
+	defer c.client.Close()
 	capResponse, err := c.client.Capabilities(ctx, req)
 	return capResponse, errors.FromGRPC(err)
 }
 
 // Get calls gnmi Get RPC
 func (c *client) Get(ctx context.Context, req *gpb.GetRequest) (*gpb.GetResponse, error) {
-	getResponse, err := c.client.Get(ctx, req)
+	c.client = c.getGNMIClient(ctx)
 	defer c.client.Close()
+	getResponse, err := c.client.Get(ctx, req)
 	return getResponse, errors.FromGRPC(err)
 }
 
@@ -22,6 +22,7 @@ type GNMIPusher struct {
 	path       string
 	payload    string
 	target     string
+	encoding   gnmiapi.Encoding
 	pushClient Client
 }
 
@@ -48,23 +49,28 @@ func NewGNMIPusher(url string, target string, payload string, path string, secur
 	return NewGNMIPusherWithClient(url, target, payload, path, gpc)
 }
 
-// NewGNMIPusherWithClient allocates a gnmi pusher for a given endpoint
+// NewGNMIPusherWithClient allocates a gnmi pusher for a given endpoint that pushes bytes
 func NewGNMIPusherWithClient(url string, target string, payload string, path string, pushClient Client) PusherInterface {
+	return NewGNMIPusherWithEncoding(url, target, payload, path, gnmiapi.Encoding_BYTES, pushClient)
+}
+
+// NewGNMIPusherWithEncoding allocates a gnmi pusher for a given endpoint that pushes the payload as a
+// BYTES, PROTO or ASCII value
+func NewGNMIPusherWithEncoding(url string, target string, payload string, path string, encoding gnmiapi.Encoding, pushClient Client) *GNMIPusher {
 	gnmiPusher := &GNMIPusher{
 		endpoint:   url,
 		pushClient: pushClient,
 		payload:    payload,
 		target:     target,
 		path:       path,
+		encoding:   encoding,
 	}
 
 	return gnmiPusher
 }
 
-// PushUpdate pushes an update to the GNMI server.
-func (p *GNMIPusher) PushUpdate() error {
-	setGnmiRequest := &gnmiapi.SetRequest{}
-
+// gnmiPath returns the gnmi path that the pusher replaces, the root if no path is set
+func (p *GNMIPusher) gnmiPath() *gnmiapi.Path {
 	var es []*gnmiapi.PathElem
 	if p.path != "" {
 		e := &gnmiapi.PathElem{
@@ -72,15 +78,26 @@ func (p *GNMIPusher) PushUpdate() error {
 		}
 		es = []*gnmiapi.PathElem{e}
 	}
-	path := &gnmiapi.Path{
+	return &gnmiapi.Path{
 		Origin: "",
 		Elem:   es,
 		Target: p.target,
 	}
-	tv := &gnmiapi.TypedValue{
-		Value: &gnmiapi.TypedValue_BytesVal{
-			BytesVal: []byte(p.payload),
-		},
+}
+
+// PushUpdate pushes an update to the GNMI server.
+func (p *GNMIPusher) PushUpdate() error {
+	setGnmiRequest := &gnmiapi.SetRequest{}
+
+	path := p.gnmiPath()
+	tv := &gnmiapi.TypedValue{}
+	switch p.encoding {
+	case gnmiapi.Encoding_PROTO:
+		tv.Value = &gnmiapi.TypedValue_ProtoBytes{ProtoBytes: []byte(p.payload)}
+	case gnmiapi.Encoding_ASCII:
+		tv.Value = &gnmiapi.TypedValue_AsciiVal{AsciiVal: p.payload}
+	default:
+		tv.Value = &gnmiapi.TypedValue_BytesVal{BytesVal: []byte(p.payload)}
 	}
 	ud := &gnmiapi.Update{
 		Path:       path,
@@ -105,6 +122,47 @@ func (p *GNMIPusher) PushUpdate() error {
 	return nil
 }
 
+// Readback gets the path that was pushed back from the GNMI server, returning the bytes of its value
+// so that the caller can check that the server adopted the payload.
+func (p *GNMIPusher) Readback() ([]byte, error) {
+	getGnmiRequest := &gnmiapi.GetRequest{
+		Path:     []*gnmiapi.Path{p.gnmiPath()},
+		Type:     gnmiapi.GetRequest_CONFIG,
+		Encoding: p.encoding,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	getResponse, err := p.pushClient.Get(ctx, getGnmiRequest)
+	if err != nil {
+		return nil, &PushError{
+			Endpoint:   p.endpoint,
+			StatusCode: 500,
+			Status:     err.Error(),
+			Operation:  "GET",
+		}
+	}
+
+	for _, notification := range getResponse.GetNotification() {
+		for _, update := range notification.GetUpdate() {
+			switch val := update.GetVal().GetValue().(type) {
+			case *gnmiapi.TypedValue_BytesVal:
+				return val.BytesVal, nil
+			case *gnmiapi.TypedValue_ProtoBytes:
+				return val.ProtoBytes, nil
+			case *gnmiapi.TypedValue_AsciiVal:
+				return []byte(val.AsciiVal), nil
+			}
+		}
+	}
+	return nil, &PushError{
+		Endpoint:   p.endpoint,
+		StatusCode: 500,
+		Status:     "get response has no bytes value",
+		Operation:  "GET",
+	}
+}
+
 // PushDelete pushes a delete operation to the GNMI server
 func (p *GNMIPusher) PushDelete() error {
 	return nil
@@ -18,19 +18,39 @@ import (
 )
 
 type testClient struct {
-	payload        string
-	expectedStatus int32
+	payload            string
+	expectedStatus     int32
+	getRequest         *gpb.GetRequest
+	getResponse        *gpb.GetResponse // nil echoes the last set value
+	setVal             *gpb.TypedValue
+	capabilities       *gpb.CapabilityResponse // nil supports BYTES
+	capabilityRequests int
 }
 
-func (*testClient) Capabilities(ctx context.Context, r *gpb.CapabilityRequest) (*gpb.CapabilityResponse, error) {
-	return nil, nil
+func (tc *testClient) Capabilities(ctx context.Context, r *gpb.CapabilityRequest) (*gpb.CapabilityResponse, error) {
+	tc.capabilityRequests++
+	if tc.expectedStatus != http.StatusOK {
+		return nil, errors.New(tc.expectedStatus, "gnmi capabilities operation failed")
+	}
+	if tc.capabilities != nil {
+		return tc.capabilities, nil
+	}
+	return &gpb.CapabilityResponse{GNMIVersion: "0.7.0", SupportedEncodings: []gpb.Encoding{gpb.Encoding_BYTES}}, nil
 }
-func (*testClient) Get(ctx context.Context, r *gpb.GetRequest) (*gpb.GetResponse, error) {
-	return nil, nil
+func (tc *testClient) Get(ctx context.Context, r *gpb.GetRequest) (*gpb.GetResponse, error) {
+	tc.getRequest = r
+	if tc.expectedStatus != http.StatusOK {
+		return nil, errors.New(tc.expectedStatus, "gnmi get operation failed")
+	}
+	if tc.getResponse != nil {
+		return tc.getResponse, nil
+	}
+	return testGetResponse(tc.setVal), nil
 }
 func (tc *testClient) Set(ctx context.Context, r *gpb.SetRequest) (*gpb.SetResponse, error) {
 	if tc.expectedStatus == http.StatusOK {
 		tc.payload = r.String()
+		tc.setVal = r.Replace[0].Val
 		return nil, nil
 	}
 	return nil, errors.New(tc.expectedStatus, "gnmi set operation failed")
@@ -57,3 +77,50 @@ func TestGNMIPushError(t *testing.T) {
 	assert.NotNil(t, pushError)
 	assert.Greater(t, pushError.StatusCode, 0)
 }
+
+func testGetResponse(val *gpb.TypedValue) *gpb.GetResponse {
+	return &gpb.GetResponse{
+		Notification: []*gpb.Notification{{Update: []*gpb.Update{{Val: val}}}},
+	}
+}
+
+// TestGNMIReadback tests that the pusher reads back the value of the path it pushed
+func TestGNMIReadback(t *testing.T) {
+	tc := &testClient{expectedStatus: http.StatusOK}
+	pusher := NewGNMIPusherWithClient("someURL", "stratum", "somepayload", "path", tc).(*GNMIPusher)
+	assert.NoError(t, pusher.PushUpdate())
+	readback, err := pusher.Readback()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("somepayload"), readback)
+	assert.Equal(t, "path", tc.getRequest.Path[0].Elem[0].Name)
+	assert.Equal(t, "stratum", tc.getRequest.Path[0].Target)
+
+	tc.getResponse = testGetResponse(&gpb.TypedValue{Value: &gpb.TypedValue_AsciiVal{AsciiVal: "ascii"}})
+	readback, err = pusher.Readback()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ascii"), readback)
+
+	tc.getResponse = &gpb.GetResponse{}
+	_, err = pusher.Readback()
+	assert.EqualError(t, err, "Push Error op=GET endpoint=someURL code=500 status=get response has no bytes value")
+
+	tc.expectedStatus = http.StatusForbidden
+	_, err = pusher.Readback()
+	assert.Error(t, err)
+	assert.Equal(t, "GET", err.(*PushError).Operation)
+}
+
+// TestGNMIPushEncodings tests that the payload is pushed and read back in the pusher's encoding
+func TestGNMIPushEncodings(t *testing.T) {
+	tc := &testClient{expectedStatus: http.StatusOK}
+	pusher := NewGNMIPusherWithEncoding("someURL", "stratum", "somepayload", "", gpb.Encoding_PROTO, tc)
+	assert.NoError(t, pusher.PushUpdate())
+	assert.Equal(t, []byte("somepayload"), tc.setVal.GetProtoBytes())
+	_, err := pusher.Readback()
+	assert.NoError(t, err)
+	assert.Equal(t, gpb.Encoding_PROTO, tc.getRequest.Encoding)
+
+	pusher = NewGNMIPusherWithEncoding("someURL", "stratum", "somepayload", "", gpb.Encoding_ASCII, tc)
+	assert.NoError(t, pusher.PushUpdate())
+	assert.Equal(t, "somepayload", tc.setVal.GetAsciiVal())
+}
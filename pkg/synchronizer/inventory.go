@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+/*
+ * inventory.go: a read-only HTTP API for what the adapter knows about the switches it manages,
+ * served on its own port (see the inventoryAddress flag of fabric-adapter). It carries the gNMI
+ * capabilities of the switches and the SID API of the store package.
+ *
+ * Examples:
+ *   # list the gNMI capabilities of every switch
+ *   curl http://localhost:9852/switches
+ *
+ *   # look up the gNMI capabilities of one switch
+ *   curl http://localhost:9852/switches/leaf-one
+ *
+ *   # list all SID allocations
+ *   curl http://localhost:9852/sids
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/onosproject/fabric-adapter/pkg/store"
+	"net/http"
+	"strings"
+)
+
+// SwitchHandlerPrefix is the path the switch capability inventory is served under
+const SwitchHandlerPrefix = "/switches"
+
+// NewInventoryHandler creates the HTTP handler of the inventory API. The SID API is only served
+// once the synchronizer has started its SID store.
+func (s *Synchronizer) NewInventoryHandler() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc(SwitchHandlerPrefix, s.serveSwitchCapabilities)
+	mux.HandleFunc(SwitchHandlerPrefix+"/", s.serveSwitchCapabilities)
+
+	if s.sidStore != nil {
+		sidHandler := store.NewSIDHandler(s.sidStore)
+		mux.Handle(store.SIDHandlerPrefix, sidHandler)
+		mux.Handle(store.SIDHandlerPrefix+"/", sidHandler)
+	} else {
+		log.Warn("SID store unavailable, not serving the SID API")
+	}
+	return mux
+}
+
+// serveSwitchCapabilities lists the capabilities of every switch, or looks up those of one switch
+func (s *Synchronizer) serveSwitchCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var value interface{}
+	inventory := s.GetSwitchCapabilities()
+	switchID := strings.Trim(strings.TrimPrefix(r.URL.Path, SwitchHandlerPrefix), "/")
+	if switchID == "" {
+		value = inventory
+	} else if capabilities, okay := inventory[switchID]; okay {
+		value = capabilities
+	} else {
+		http.Error(w, fmt.Sprintf("switch %s has no gNMI capabilities", switchID), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/onosproject/fabric-adapter/pkg/store"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestInventoryHandler checks the list and lookup endpoints of the switch capability inventory
+func TestInventoryHandler(t *testing.T) {
+	s := Synchronizer{}
+	scope, _, _ := newAppsScope()
+	tc := &testClient{expectedStatus: http.StatusOK, capabilities: &gpb.CapabilityResponse{
+		GNMIVersion:        "0.7.0",
+		SupportedEncodings: []gpb.Encoding{gpb.Encoding_PROTO},
+		SupportedModels:    []*gpb.ModelData{{Name: "openconfig-platform", Organization: "OpenConfig working group", Version: "0.13.0"}},
+	}}
+	_, err := s.lookupSwitchCapabilities(scope, "leaf:9339", tc)
+	assert.NoError(t, err)
+
+	ts := httptest.NewServer(s.NewInventoryHandler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + SwitchHandlerPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var inventory map[string]SwitchCapabilities
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&inventory))
+	assert.NoError(t, resp.Body.Close())
+	assert.Len(t, inventory, 1)
+
+	resp, err = http.Get(ts.URL + SwitchHandlerPrefix + "/" + deviceTestLeafID)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var leaf SwitchCapabilities
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&leaf))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, "leaf:9339", leaf.Endpoint)
+	assert.Equal(t, "PROTO", leaf.Encoding)
+	assert.Equal(t, chassisConfigPath, leaf.Path)
+	assert.Equal(t, []SwitchCapabilityModel{{Name: "openconfig-platform", Organization: "OpenConfig working group", Version: "0.13.0"}}, leaf.Models)
+
+	resp, err = http.Get(ts.URL + SwitchHandlerPrefix + "/leaf-two")
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(ts.URL+SwitchHandlerPrefix, "application/json", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+// TestInventorySIDs checks that the SID API is served by the inventory API once the SID store is up
+func TestInventorySIDs(t *testing.T) {
+	s := Synchronizer{}
+	ts := httptest.NewServer(s.NewInventoryHandler())
+	resp, err := http.Get(ts.URL + store.SIDHandlerPrefix)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	ts.Close()
+
+	testAtomix, sidStore := getAtomixStore(t)
+	s.sidStore = sidStore
+	sid, err := sidStore.Get(context.Background(), deviceTestLeafID)
+	assert.NoError(t, err)
+
+	ts = httptest.NewServer(s.NewInventoryHandler())
+	defer ts.Close()
+
+	resp, err = http.Get(ts.URL + store.SIDHandlerPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var entries []store.SIDEntry
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, []store.SIDEntry{{SwitchID: deviceTestLeafID, SID: sid}}, entries)
+
+	resp, err = http.Get(ts.URL + store.SIDHandlerPrefix + "/" + deviceTestLeafID)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var entry store.SIDEntry
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&entry))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, sid, entry.SID)
+
+	assert.NoError(t, testAtomix.Stop())
+}
@@ -50,4 +50,69 @@ var (
 	},
 		[]string{"enterprise", "kind", "destination"},
 	)
+
+	// KpiOnosControllerHealthy is 1 if an ONOS cluster member passed its last health check, 0 otherwise
+	KpiOnosControllerHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "onos_controller_healthy",
+		Help: "Whether the ONOS cluster member passed its last health check",
+	},
+		[]string{"fabric", "controller"},
+	)
+
+	// KpiOnosControllerFailoverTotal is a count of pushes that moved to another ONOS cluster member
+	KpiOnosControllerFailoverTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onos_controller_failover_total",
+		Help: "The total number of failovers to another ONOS cluster member",
+	},
+		[]string{"fabric"},
+	)
+
+	// KpiOnosPushTotal is a count of pushes to ONOS, labeled with the cluster member that served them
+	KpiOnosPushTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onos_push_total",
+		Help: "The total number of pushes to ONOS cluster members",
+	},
+		[]string{"fabric", "kind", "controller"},
+	)
+
+	// KpiOnosAppActive is 1 if an ONOS application needed by a fabric is active, 0 otherwise
+	KpiOnosAppActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "onos_app_active",
+		Help: "Whether an ONOS application required by the fabric netcfg is active",
+	},
+		[]string{"fabric", "app"},
+	)
+
+	// KpiOnosAppActivationTotal is a count of attempts to activate ONOS applications
+	KpiOnosAppActivationTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onos_app_activation_total",
+		Help: "The total number of ONOS application activations attempted",
+	},
+		[]string{"fabric", "app"},
+	)
+
+	// KpiStratumVerifyMismatchTotal is a count of pushes whose read back chassis config differed
+	KpiStratumVerifyMismatchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stratum_verify_mismatch_total",
+		Help: "The total number of chassis config pushes that the switch did not adopt as sent",
+	},
+		[]string{"fabric", "switch"},
+	)
+
+	// KpiStratumRefusedTotal is a count of chassis config pushes skipped because the switch supports no usable encoding
+	KpiStratumRefusedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stratum_refused_total",
+		Help: "The total number of chassis config pushes refused for lack of a supported gNMI encoding",
+	},
+		[]string{"fabric", "switch"},
+	)
+
+	// KpiSwitchGnmiCapabilities is 1 for the gNMI version of each switch and the encoding its chassis config
+	// is pushed in, which is empty if the switch was refused
+	KpiSwitchGnmiCapabilities = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "switch_gnmi_capabilities",
+		Help: "The gNMI version of a switch and the chassis config encoding negotiated with it",
+	},
+		[]string{"fabric", "switch", "gnmi_version", "encoding"},
+	)
 )
@@ -9,12 +9,16 @@ package synchronizer
 import (
 	"context"
 	"fmt"
+	"github.com/onosproject/fabric-adapter/pkg/stratum_hal"
 	topoapi "github.com/onosproject/onos-api/go/onos/topo"
 	"github.com/onosproject/onos-lib-go/pkg/certs"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/onosproject/onos-lib-go/pkg/grpc/retry"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Functions here to ease in looking things up
@@ -50,6 +54,349 @@ func lookupSwitchVlan(sw *Switch, id *uint16) (*SwitchVlan, error) {
 	return vlan, nil
 }
 
+// lookupPortSubnets returns the subnets of all VLANs configured on a port
+func lookupPortSubnets(sw *Switch, p *Port) ([]string, error) {
+	subnets := []string{}
+	if p.Vlans == nil {
+		return subnets, nil
+	}
+	if p.Vlans.Untagged != nil {
+		vlan, err := lookupSwitchVlan(sw, p.Vlans.Untagged)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, vlan.Subnet...)
+	}
+	for _, vlanID := range p.Vlans.Tagged {
+		vlan, err := lookupSwitchVlan(sw, &vlanID)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, vlan.Subnet...)
+	}
+	return subnets, nil
+}
+
+// lookupDhcpServerConnectPoints returns the ports that name the given DHCP server as
+// their DhcpConnectPoint, ordered by switch and port
+func lookupDhcpServerConnectPoints(scope *FabricScope, serverID string) []*connectPoint {
+	connectPoints := []*connectPoint{}
+	for _, swID := range sortedSwitchIDs(scope.Fabric) {
+		sw := scope.Fabric.Switch[swID]
+		model, _ := lookupSwitchModel(scope, sw.ModelId)
+		for _, key := range sortedPortKeys(sw) {
+			p := sw.Port[key]
+			for _, id := range p.DhcpConnectPoint {
+				if id == serverID {
+					connectPoints = append(connectPoints, &connectPoint{Switch: sw, Model: model, Port: p})
+					break
+				}
+			}
+		}
+	}
+	return connectPoints
+}
+
+// lookupSwitchPort returns the port of a switch given as "<cage>/<channel>" or "<cage>"
+func lookupSwitchPort(sw *Switch, name string) (*Port, error) {
+	var key SwitchPortKey
+	parts := strings.SplitN(name, "/", 2)
+	cage, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("Switch %s port %s is not of the form <cage>/<channel>", *sw.SwitchId, name)
+	}
+	key.CageNumber = uint8(cage)
+	if len(parts) > 1 {
+		channel, err := strconv.ParseUint(parts[1], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("Switch %s port %s is not of the form <cage>/<channel>", *sw.SwitchId, name)
+		}
+		key.ChannelNumber = uint8(channel)
+	}
+	p, okay := sw.Port[key]
+	if !okay {
+		return nil, fmt.Errorf("Switch %s has no port %s", *sw.SwitchId, name)
+	}
+	return p, nil
+}
+
+// lookupSwitchAttribute returns the value of a switch attribute and whether it is set
+func lookupSwitchAttribute(sw *Switch, key string) (string, bool) {
+	attr, okay := sw.Attribute[key]
+	if !okay || attr.Value == nil {
+		return "", false
+	}
+	return *attr.Value, true
+}
+
+// lookupSwitchModelAttribute returns the value of a switch model attribute and whether it is set
+func lookupSwitchModelAttribute(model *SwitchModel, key string) (string, bool) {
+	attr, okay := model.Attribute[key]
+	if !okay || attr.Value == nil {
+		return "", false
+	}
+	return *attr.Value, true
+}
+
+// lookupPortParameter returns a port parameter, from the most specific place that sets it: the
+// switch attribute port.<cage>/<channel>.<parameter>, then the switch model attribute
+// <parameter>.<speed> (for example fec.speed-25g), then the switch model attribute <parameter>.
+func lookupPortParameter(sw *Switch, model *SwitchModel, p *Port, parameter string) (string, bool) {
+	portName := fmt.Sprintf("%d/%d", *p.CageNumber, *p.ChannelNumber)
+	if value, okay := lookupAttributeGroups(sw, portAttributePrefix)[portName][parameter]; okay {
+		return value, true
+	}
+	if value, okay := lookupSwitchModelAttribute(model, parameter+"."+p.Speed.String()); okay {
+		return value, true
+	}
+	return lookupSwitchModelAttribute(model, parameter)
+}
+
+// lookupSwitchModelPlatform returns the Stratum platform of a switch model from its "platform"
+// attribute, which names a stratum_hal.Platform value with or without the "PLT_" prefix. Models
+// without the attribute are Tofino.
+func lookupSwitchModelPlatform(model *SwitchModel) (stratum_hal.Platform, error) {
+	attr, okay := model.Attribute[platformAttribute]
+	if !okay || attr.Value == nil {
+		return stratum_hal.Platform_PLT_GENERIC_BAREFOOT_TOFINO, nil
+	}
+	name := strings.ToUpper(strings.TrimSpace(*attr.Value))
+	if !strings.HasPrefix(name, "PLT_") {
+		name = "PLT_" + name
+	}
+	value, okay := stratum_hal.Platform_value[name]
+	if !okay || stratum_hal.Platform(value) == stratum_hal.Platform_PLT_UNKNOWN {
+		return stratum_hal.Platform_PLT_UNKNOWN, fmt.Errorf("SwitchModel platform %s is not supported", *attr.Value)
+	}
+	return stratum_hal.Platform(value), nil
+}
+
+// lookupAttributeGroups collects switch attributes of the form "<prefix>.<name>.<field>",
+// returning the fields keyed by name
+func lookupAttributeGroups(sw *Switch, prefix string) map[string]map[string]string {
+	values := map[string]string{}
+	for key, attr := range sw.Attribute {
+		if attr.Value != nil {
+			values[key] = *attr.Value
+		}
+	}
+	return groupAttributes(values, prefix)
+}
+
+// lookupSwitchModelAttributeGroups is lookupAttributeGroups for switch model attributes
+func lookupSwitchModelAttributeGroups(model *SwitchModel, prefix string) map[string]map[string]string {
+	values := map[string]string{}
+	for key, attr := range model.Attribute {
+		if attr.Value != nil {
+			values[key] = *attr.Value
+		}
+	}
+	return groupAttributes(values, prefix)
+}
+
+func groupAttributes(values map[string]string, prefix string) map[string]map[string]string {
+	groups := map[string]map[string]string{}
+	for key, value := range values {
+		if !strings.HasPrefix(key, prefix+".") {
+			continue
+		}
+		rest := strings.TrimPrefix(key, prefix+".")
+		dot := strings.LastIndex(rest, ".")
+		if dot <= 0 {
+			continue
+		}
+		name, field := rest[:dot], rest[dot+1:]
+		if groups[name] == nil {
+			groups[name] = map[string]string{}
+		}
+		groups[name][field] = value
+	}
+	return groups
+}
+
+// lookupQosAttributeGroups collects attribute groups from the switch model, overridden field by
+// field by the same groups on the switch
+func lookupQosAttributeGroups(sw *Switch, model *SwitchModel, prefix string) map[string]map[string]string {
+	groups := lookupSwitchModelAttributeGroups(model, prefix)
+	for name, fields := range lookupAttributeGroups(sw, prefix) {
+		if groups[name] == nil {
+			groups[name] = map[string]string{}
+		}
+		for field, value := range fields {
+			groups[name][field] = value
+		}
+	}
+	return groups
+}
+
+// lookupChassisNodes returns the Stratum nodes of a switch model, and the node serving each cage.
+// Nodes are described by the model attributes node.<id>.slot, node.<id>.index and node.<id>.cages,
+// where cages is a list of cages and cage ranges such as "1-16,33". Slot and index default to 1,
+// and a lone node serves every cage unless told otherwise. A model without node attributes is a
+// single node in slot 1.
+func lookupChassisNodes(model *SwitchModel) ([]*stratum_hal.Node, map[uint8]*stratum_hal.Node, error) {
+	groups := lookupSwitchModelAttributeGroups(model, chassisNodeAttributePrefix)
+	if len(groups) == 0 {
+		node := &stratum_hal.Node{Id: 1, Slot: 1, Index: 1}
+		byCage := map[uint8]*stratum_hal.Node{}
+		for cage := range model.Port {
+			byCage[cage] = node
+		}
+		return []*stratum_hal.Node{node}, byCage, nil
+	}
+
+	nodes := []*stratum_hal.Node{}
+	byCage := map[uint8]*stratum_hal.Node{}
+	positions := map[string]uint64{}
+	for _, name := range sortedKeys(groups) {
+		fields := groups[name]
+		id, err := strconv.ParseUint(name, 10, 64)
+		if err != nil || id == 0 {
+			return nil, nil, fmt.Errorf("SwitchModel node id %s is not a positive number", name)
+		}
+		node := &stratum_hal.Node{Id: id, Slot: 1, Index: 1}
+		if value, okay := fields["slot"]; okay {
+			slot, err := strconv.ParseUint(value, 10, 31)
+			if err != nil || slot == 0 {
+				return nil, nil, fmt.Errorf("SwitchModel node %d has invalid slot %s", id, value)
+			}
+			node.Slot = int32(slot)
+		}
+		if value, okay := fields["index"]; okay {
+			index, err := strconv.ParseUint(value, 10, 31)
+			if err != nil || index == 0 {
+				return nil, nil, fmt.Errorf("SwitchModel node %d has invalid index %s", id, value)
+			}
+			node.Index = int32(index)
+		}
+		position := fmt.Sprintf("%d/%d", node.Slot, node.Index)
+		if other, okay := positions[position]; okay {
+			return nil, nil, fmt.Errorf("SwitchModel nodes %d and %d are both at slot %d index %d", other, id, node.Slot, node.Index)
+		}
+		positions[position] = id
+
+		cages := []uint8{}
+		if value, okay := fields["cages"]; okay {
+			cages, err = parseCageList(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("SwitchModel node %d: %s", id, err)
+			}
+		} else if len(groups) == 1 {
+			for cage := range model.Port {
+				cages = append(cages, cage)
+			}
+		} else {
+			return nil, nil, fmt.Errorf("SwitchModel node %d has no cages", id)
+		}
+		for _, cage := range cages {
+			if _, okay := model.Port[cage]; !okay {
+				return nil, nil, fmt.Errorf("SwitchModel node %d cage %d is not a port of the model", id, cage)
+			}
+			if other, okay := byCage[cage]; okay {
+				return nil, nil, fmt.Errorf("SwitchModel cage %d is on both node %d and node %d", cage, other.Id, id)
+			}
+			byCage[cage] = node
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Id < nodes[j].Id })
+	return nodes, byCage, nil
+}
+
+// switchTrunk is a group of switch ports aggregated into one logical port
+type switchTrunk struct {
+	ID      uint32
+	Name    string
+	Type    stratum_hal.TrunkPort_TrunkType
+	Members []*Port
+}
+
+// lookupSwitchTrunk parses the trunk.<id>.members, trunk.<id>.type and trunk.<id>.name attributes of
+// a switch. The id is the port ID of the trunk, members is a list of "<cage>/<channel>" ports that
+// must share their vlans, and type is "lacp" (the default) or "static".
+func lookupSwitchTrunk(sw *Switch, model *SwitchModel, name string, fields map[string]string) (*switchTrunk, error) {
+	id, err := strconv.ParseUint(name, 10, 32)
+	if err != nil || id == 0 {
+		return nil, fmt.Errorf("Switch %s trunk id %s is not a positive number", *sw.SwitchId, name)
+	}
+	trunk := &switchTrunk{
+		ID:   uint32(id),
+		Name: fmt.Sprintf("trunk-%d", id),
+		Type: stratum_hal.TrunkPort_LACP_TRUNK,
+	}
+	if value, okay := fields["name"]; okay {
+		trunk.Name = value
+	}
+	switch fields["type"] {
+	case "", "lacp":
+	case "static":
+		trunk.Type = stratum_hal.TrunkPort_STATIC_TRUNK
+	default:
+		return nil, fmt.Errorf("Switch %s trunk %d has invalid type %s", *sw.SwitchId, trunk.ID, fields["type"])
+	}
+
+	for _, p := range sw.Port {
+		if portNumber(model, *p.CageNumber, *p.ChannelNumber) == trunk.ID {
+			return nil, fmt.Errorf("Switch %s trunk %d has the same id as port %d/%d", *sw.SwitchId, trunk.ID, *p.CageNumber, *p.ChannelNumber)
+		}
+	}
+
+	if fields["members"] == "" {
+		return nil, fmt.Errorf("Switch %s trunk %d has no members", *sw.SwitchId, trunk.ID)
+	}
+	for _, portName := range strings.Split(fields["members"], ",") {
+		p, err := lookupSwitchPort(sw, portName)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range trunk.Members {
+			if member == p {
+				return nil, fmt.Errorf("Switch %s trunk %d lists port %s twice", *sw.SwitchId, trunk.ID, portName)
+			}
+		}
+		if len(trunk.Members) > 0 && portVlanSummary(p) != portVlanSummary(trunk.Members[0]) {
+			return nil, fmt.Errorf("Switch %s trunk %d port %s has different vlans from the other members", *sw.SwitchId, trunk.ID, portName)
+		}
+		trunk.Members = append(trunk.Members, p)
+	}
+	sort.Slice(trunk.Members, func(i, j int) bool {
+		return portNumber(model, *trunk.Members[i].CageNumber, *trunk.Members[i].ChannelNumber) <
+			portNumber(model, *trunk.Members[j].CageNumber, *trunk.Members[j].ChannelNumber)
+	})
+
+	return trunk, nil
+}
+
+// lookupPairLocalPort returns the port that carries the pair link of a paired leaf. A single pairing
+// port is used as is; several pairing ports must be exactly the members of one trunk, which ONOS
+// then sees as the pair link, so that every pairing port carries it.
+func lookupPairLocalPort(sw *Switch, model *SwitchModel) (uint32, error) {
+	pairingPorts := pairingPortKeys(sw)
+	if len(pairingPorts) == 1 {
+		return portNumber(model, pairingPorts[0].CageNumber, pairingPorts[0].ChannelNumber), nil
+	}
+
+	trunks := lookupAttributeGroups(sw, trunkAttributePrefix)
+	for _, name := range sortedKeys(trunks) {
+		trunk, err := lookupSwitchTrunk(sw, model, name, trunks[name])
+		if err != nil || len(trunk.Members) != len(pairingPorts) {
+			continue
+		}
+		members := map[SwitchPortKey]bool{}
+		for _, member := range trunk.Members {
+			members[SwitchPortKey{CageNumber: *member.CageNumber, ChannelNumber: *member.ChannelNumber}] = true
+		}
+		matched := true
+		for _, key := range pairingPorts {
+			matched = matched && members[key]
+		}
+		if matched {
+			return trunk.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("Switch %s has %d pairing ports, which must be the members of one trunk", *sw.SwitchId, len(pairingPorts))
+}
+
 func getTopoClient(ctx context.Context, s *Synchronizer) (topoapi.TopoClient, error) {
 	opts, err := certs.HandleCertPaths(s.caPath, s.keyPath, s.certPath, true)
 	if err != nil {
@@ -69,12 +416,20 @@ func getTopoClient(ctx context.Context, s *Synchronizer) (topoapi.TopoClient, er
 	return client, nil
 }
 
-func lookupFabricControllerInfo(ctx context.Context, s *Synchronizer, fabricName string) (*topoapi.ControllerInfo, error) {
+// lookupFabricControllers returns the ONOS cluster members that control a fabric. The
+// ControllerInfo aspect of the fabric entity comes first, followed by the ControllerInfo of
+// every entity that has a "controls" relation to the fabric, ordered by entity ID.
+func lookupFabricControllers(ctx context.Context, s *Synchronizer, fabricName string) ([]*topoapi.ControllerInfo, error) {
 	topoClient, err := getTopoClient(ctx, s)
 	if err != nil {
 		return nil, errors.FromGRPC(err)
 	}
+	return listFabricControllers(ctx, topoClient, fabricName)
+}
 
+// listFabricControllers does the lookups of lookupFabricControllers with the given topo client.
+// If the relations can not be listed, the fabric aspect alone is used.
+func listFabricControllers(ctx context.Context, topoClient topoapi.TopoClient, fabricName string) ([]*topoapi.ControllerInfo, error) {
 	getResponse, err := topoClient.Get(ctx, &topoapi.GetRequest{
 		ID: topoapi.ID(fabricName),
 	})
@@ -83,13 +438,66 @@ func lookupFabricControllerInfo(ctx context.Context, s *Synchronizer, fabricName
 	}
 	log.Debug("topo response object: %v", getResponse.Object)
 
-	fabricObject := getResponse.Object
+	controllers := []*topoapi.ControllerInfo{}
 	controllerInfo := &topoapi.ControllerInfo{}
-	err = fabricObject.GetAspect(controllerInfo)
+	err = getResponse.Object.GetAspect(controllerInfo)
+	if err == nil && controllerInfo.ControlEndpoint != nil {
+		controllers = append(controllers, controllerInfo)
+	}
+
+	listResponse, err := topoClient.List(ctx, &topoapi.ListRequest{
+		Filters: &topoapi.Filters{
+			KindFilter: &topoapi.Filter{
+				Filter: &topoapi.Filter_Equal_{Equal_: &topoapi.EqualFilter{Value: topoapi.CONTROLS}},
+			},
+			ObjectTypes: []topoapi.Object_Type{topoapi.Object_RELATION},
+		},
+	})
 	if err != nil {
-		return nil, errors.FromGRPC(err)
+		if len(controllers) == 0 {
+			return nil, errors.FromGRPC(err)
+		}
+		// log the error and continue with the controller of the fabric aspect
+		log.Warnf("Fabric %s controls relations lookup failed: %v", fabricName, errors.FromGRPC(err))
+		listResponse = &topoapi.ListResponse{}
+	}
+
+	// only the relations from a controller to this fabric
+	controllerIDs := []string{}
+	for _, object := range listResponse.Objects {
+		relation := object.GetRelation()
+		if relation == nil || relation.KindID != topoapi.CONTROLS {
+			continue
+		}
+		if string(relation.TgtEntityID) == fabricName && string(relation.SrcEntityID) != fabricName {
+			controllerIDs = append(controllerIDs, string(relation.SrcEntityID))
+		}
+	}
+	sort.Strings(controllerIDs)
+
+	for _, controllerID := range controllerIDs {
+		getResponse, err := topoClient.Get(ctx, &topoapi.GetRequest{
+			ID: topoapi.ID(controllerID),
+		})
+		if err != nil {
+			log.Warnf("Fabric %s controller %s lookup failed: %v", fabricName, controllerID, errors.FromGRPC(err))
+			continue
+		}
+		controllerInfo := &topoapi.ControllerInfo{}
+		err = getResponse.Object.GetAspect(controllerInfo)
+		if err != nil || controllerInfo.ControlEndpoint == nil {
+			log.Warnf("Fabric %s controller %s has no ControllerInfo", fabricName, controllerID)
+			continue
+		}
+		controllers = append(controllers, controllerInfo)
+	}
+
+	if len(controllers) == 0 {
+		return nil, errors.NewNotFound("fabric %s has no controllers", fabricName)
+	}
+	for _, controllerInfo := range controllers {
+		log.Debug("controller address %v port %v", controllerInfo.ControlEndpoint.Address, controllerInfo.ControlEndpoint.Port)
 	}
-	log.Debug("controller address %v port %v", controllerInfo.ControlEndpoint.Address, controllerInfo.ControlEndpoint.Port)
 
-	return controllerInfo, nil
+	return controllers, nil
 }
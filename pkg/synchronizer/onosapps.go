@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// ONOS application support: netcfg for an application that is not active is silently
+// ignored, so make sure everything the rendered netcfg depends on is running first.
+
+package synchronizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	onosSegmentRoutingApp = "org.onosproject.segmentrouting"
+	onosFabricTnaApp      = "org.stratumproject.fabric-tna"
+	onosAppStateActive    = "ACTIVE"
+)
+
+// onosAppsByConfig maps a netcfg app subject to the ONOS application that consumes it, for the
+// subjects that are not themselves the application name
+var onosAppsByConfig = map[string]string{
+	onosIntAppName: onosFabricTnaApp,
+}
+
+type onosApplication struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+type onosApplications struct {
+	Applications []onosApplication `json:"applications"`
+}
+
+// requiredOnosApps returns the ONOS applications that must be active for the netcfg to take effect
+func requiredOnosApps(netConfig *OnosNetConfig) []string {
+	apps := []string{}
+	if len(netConfig.Devices) > 0 {
+		apps = appendUnique(apps, onosSegmentRoutingApp)
+	}
+	for _, device := range netConfig.Devices {
+		if strings.HasPrefix(device.Basic.PipeConf, "org.stratumproject.fabric") {
+			apps = appendUnique(apps, onosFabricTnaApp)
+		}
+	}
+	for subject := range netConfig.Apps {
+		app, okay := onosAppsByConfig[subject]
+		if !okay {
+			app = subject
+		}
+		apps = appendUnique(apps, app)
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+func (s *Synchronizer) onosRequest(scope *FabricScope, method string, path string) (*http.Response, error) {
+	client := &http.Client{
+		Timeout: time.Second * 10,
+	}
+
+	url := fmt.Sprintf("%sonos/v1/%s", *scope.OnosEndpoint, path)
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(*scope.OnosUsername, *scope.OnosPassword)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		resp.Body.Close()
+		return nil, &PushError{Operation: method, Endpoint: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return resp, nil
+}
+
+// ensureOnosApps checks that the applications required by the rendered netcfg are active,
+// activating them if enabled. An error names every application that is still not active.
+func (s *Synchronizer) ensureOnosApps(scope *FabricScope) error {
+	resp, err := s.onosRequest(scope, http.MethodGet, "applications")
+	if err != nil {
+		return fmt.Errorf("Fabric %s unable to list ONOS applications: %s", *scope.FabricId, err)
+	}
+	defer resp.Body.Close()
+
+	installed := onosApplications{}
+	err = json.NewDecoder(resp.Body).Decode(&installed)
+	if err != nil {
+		return fmt.Errorf("Fabric %s unable to decode ONOS applications: %s", *scope.FabricId, err)
+	}
+	states := map[string]string{}
+	for _, app := range installed.Applications {
+		states[app.Name] = app.State
+	}
+
+	missing := []string{}
+	for _, app := range requiredOnosApps(scope.NetConfig) {
+		if states[app] == onosAppStateActive {
+			KpiOnosAppActive.WithLabelValues(*scope.FabricId, app).Set(1)
+			continue
+		}
+
+		if s.appActivateEnable {
+			log.Infof("Fabric %s activating ONOS application %s", *scope.FabricId, app)
+			KpiOnosAppActivationTotal.WithLabelValues(*scope.FabricId, app).Inc()
+			resp, err := s.onosRequest(scope, http.MethodPost, fmt.Sprintf("applications/%s/active", app))
+			if err == nil {
+				resp.Body.Close()
+				KpiOnosAppActive.WithLabelValues(*scope.FabricId, app).Set(1)
+				continue
+			}
+			log.Warnf("Fabric %s failed to activate ONOS application %s: %s", *scope.FabricId, app, err)
+		}
+
+		KpiOnosAppActive.WithLabelValues(*scope.FabricId, app).Set(0)
+		missing = append(missing, app)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("Fabric %s ONOS applications not active: %s", *scope.FabricId, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newOnosAppsServer(t *testing.T, states map[string]string, activate bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/onos/v1/applications":
+			apps := onosApplications{}
+			for name, state := range states {
+				apps.Applications = append(apps.Applications, onosApplication{Name: name, State: state})
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(apps))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/active"):
+			if !activate {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/onos/v1/applications/"), "/active")
+			states[name] = onosAppStateActive
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// TestOnosApps tests that applications required by the netcfg are activated or reported
+func TestOnosApps(t *testing.T) {
+	scope, _, _ := newAppsScope()
+	scope.OnosUsername = aStr("onos")
+	scope.OnosPassword = aStr("rocks")
+	scope.NetConfig.Devices["device:leaf"] = &onosDevice{}
+	scope.NetConfig.Apps[onosDhcpRelayAppName] = &onosApp{}
+
+	assert.Equal(t, []string{"org.onosproject.dhcprelay", onosSegmentRoutingApp}, requiredOnosApps(scope.NetConfig))
+
+	// INT is configured through fabric-tna, not a separate application
+	scope.NetConfig.Apps[onosIntAppName] = &onosApp{}
+	assert.Equal(t, []string{"org.onosproject.dhcprelay", onosSegmentRoutingApp, onosFabricTnaApp}, requiredOnosApps(scope.NetConfig))
+	delete(scope.NetConfig.Apps, onosIntAppName)
+
+	// missing applications are activated
+	states := map[string]string{onosSegmentRoutingApp: onosAppStateActive, "org.onosproject.dhcprelay": "INSTALLED"}
+	ts := newOnosAppsServer(t, states, true)
+	defer ts.Close()
+	scope.OnosEndpoint = aStr(ts.URL + "/")
+	s := Synchronizer{appActivateEnable: true}
+	assert.NoError(t, s.ensureOnosApps(scope))
+	assert.Equal(t, onosAppStateActive, states["org.onosproject.dhcprelay"])
+
+	// activation that fails is reported
+	states = map[string]string{onosSegmentRoutingApp: onosAppStateActive}
+	ts2 := newOnosAppsServer(t, states, false)
+	defer ts2.Close()
+	scope.OnosEndpoint = aStr(ts2.URL + "/")
+	err := s.ensureOnosApps(scope)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "org.onosproject.dhcprelay")
+
+	// with activation disabled nothing is posted
+	s = Synchronizer{}
+	ts3 := newOnosAppsServer(t, map[string]string{onosSegmentRoutingApp: onosAppStateActive}, true)
+	defer ts3.Close()
+	scope.OnosEndpoint = aStr(ts3.URL + "/")
+	err = s.ensureOnosApps(scope)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "org.onosproject.dhcprelay")
+}
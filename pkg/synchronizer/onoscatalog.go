@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// ONOS catalog support: a switch that references a driver or pipeconf unknown to ONOS is
+// accepted by netcfg but never connects, so check the names against what ONOS offers.
+
+package synchronizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// onosCatalogRefreshInterval is how long the drivers and pipeconfs fetched from an ONOS are reused
+const onosCatalogRefreshInterval = time.Minute * 5
+
+type onosCatalog struct {
+	drivers   map[string]bool
+	pipeconfs map[string]bool
+	fetched   time.Time
+	// err is why the catalog could not be fetched. A failed catalog is kept until the next sync
+	// pass, so that a down ONOS is asked once per pass rather than once per switch.
+	err error
+}
+
+type onosDriverList struct {
+	Drivers []struct {
+		Name string `json:"name"`
+	} `json:"drivers"`
+}
+
+type onosPipeconfList struct {
+	Pipeconfs []struct {
+		ID string `json:"id"`
+	} `json:"pipeconfs"`
+}
+
+func (s *Synchronizer) fetchOnosList(scope *FabricScope, path string, list interface{}) error {
+	resp, err := s.onosRequest(scope, http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(list)
+}
+
+// lookupOnosCatalog returns the drivers and pipeconfs available on the fabric's ONOS, fetching
+// them if there is no cached copy for the endpoint or the cached copy has expired.
+func (s *Synchronizer) lookupOnosCatalog(scope *FabricScope) (*onosCatalog, error) {
+	if s.onosCatalogs == nil {
+		s.onosCatalogs = map[string]*onosCatalog{}
+	}
+	catalog, okay := s.onosCatalogs[*scope.OnosEndpoint]
+	if okay && catalog.err != nil {
+		return nil, catalog.err
+	}
+	if okay && time.Since(catalog.fetched) < onosCatalogRefreshInterval {
+		return catalog, nil
+	}
+
+	drivers := onosDriverList{}
+	pipeconfs := onosPipeconfList{}
+	err := s.fetchOnosList(scope, "drivers", &drivers)
+	if err != nil {
+		err = fmt.Errorf("unable to list ONOS drivers: %s", err)
+	} else if err = s.fetchOnosList(scope, "pipeconfs", &pipeconfs); err != nil {
+		err = fmt.Errorf("unable to list ONOS pipeconfs: %s", err)
+	}
+	if err != nil {
+		KpiSynchronizationFailedTotal.WithLabelValues(*scope.FabricId, "catalog", *scope.OnosEndpoint).Inc()
+		s.onosCatalogs[*scope.OnosEndpoint] = &onosCatalog{fetched: time.Now(), err: err}
+		return nil, err
+	}
+
+	catalog = &onosCatalog{
+		drivers:   map[string]bool{},
+		pipeconfs: map[string]bool{},
+		fetched:   time.Now(),
+	}
+	for _, driver := range drivers.Drivers {
+		catalog.drivers[driver.Name] = true
+	}
+	for _, pipeconf := range pipeconfs.Pipeconfs {
+		catalog.pipeconfs[pipeconf.ID] = true
+	}
+	s.onosCatalogs[*scope.OnosEndpoint] = catalog
+	return catalog, nil
+}
+
+// onosCatalogFailed reports whether the catalog of the fabric's ONOS could not be fetched
+// during this sync pass, so that some switches were pushed without being checked.
+func (s *Synchronizer) onosCatalogFailed(scope *FabricScope) bool {
+	catalog, okay := s.onosCatalogs[*scope.OnosEndpoint]
+	return okay && catalog.err != nil
+}
+
+// forgetFailedOnosCatalogs drops the catalogs that could not be fetched, so that a new sync
+// pass asks for them again.
+func (s *Synchronizer) forgetFailedOnosCatalogs() {
+	for endpoint, catalog := range s.onosCatalogs {
+		if catalog.err != nil {
+			delete(s.onosCatalogs, endpoint)
+		}
+	}
+}
+
+// validateOnosDriverAndPipeconf checks that the switch driver and pipeconf are known to the
+// fabric's ONOS. If the catalog cannot be fetched the check is skipped rather than rejecting
+// every switch in the fabric; SynchronizeFabricToOnos counts that as a failure.
+func (s *Synchronizer) validateOnosDriverAndPipeconf(scope *FabricScope, driver string, pipeconf string) error {
+	if scope.OnosEndpoint == nil {
+		return nil
+	}
+	catalog, err := s.lookupOnosCatalog(scope)
+	if err != nil {
+		log.Warnf("Fabric %s not validating driver and pipeconf of switch %s: %s", *scope.FabricId, *scope.Switch.SwitchId, err)
+		return nil
+	}
+	if !catalog.drivers[driver] {
+		return fmt.Errorf("fabric %s switch %s driver %s is not available on ONOS %s", *scope.FabricId, *scope.Switch.SwitchId, driver, *scope.OnosEndpoint)
+	}
+	if !catalog.pipeconfs[pipeconf] {
+		return fmt.Errorf("fabric %s switch %s pipeconf %s is not available on ONOS %s", *scope.FabricId, *scope.Switch.SwitchId, pipeconf, *scope.OnosEndpoint)
+	}
+	return nil
+}
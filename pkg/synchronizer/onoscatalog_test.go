@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestOnosCatalog tests that switch drivers and pipeconfs are checked against those offered by ONOS
+func TestOnosCatalog(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/onos/v1/drivers":
+			_, _ = w.Write([]byte(`{"drivers": [{"name": "` + deviceTestDriverValue + `"}, {"name": "default"}]}`))
+		case "/onos/v1/pipeconfs":
+			_, _ = w.Write([]byte(`{"pipeconfs": [{"id": "` + deviceTestPipeconfValue + `"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	testAtomix, sidStore := getAtomixStore(t)
+	s := Synchronizer{sidStore: sidStore}
+	scope, onfSwitch, _ := newAppsScope()
+	scope.OnosEndpoint = aStr(ts.URL + "/")
+	scope.OnosUsername = aStr("onos")
+	scope.OnosPassword = aStr("rocks")
+
+	// a rejected switch does not use up a SID
+	onfSwitch.SwitchId = aStr("rejected")
+	addAttribute(onfSwitch, deviceTestPipeconfKey, "org.stratumproject.unknown")
+	assert.Error(t, s.handleSwitch(context.Background(), scope))
+	assert.Empty(t, scope.NetConfig.Devices)
+
+	onfSwitch.SwitchId = aStr(deviceTestLeafID)
+	addAttribute(onfSwitch, deviceTestPipeconfKey, deviceTestPipeconfValue)
+	assert.NoError(t, s.handleSwitch(context.Background(), scope))
+	assert.Len(t, scope.NetConfig.Devices, 1)
+	assert.Equal(t, uint32(101), scope.NetConfig.Devices["device:"+deviceTestLeafID].SegmentRouting.Ipv4NodeSid)
+	assert.Equal(t, 2, requests)
+
+	// the catalog is cached between syncs
+	assert.NoError(t, s.validateOnosDriverAndPipeconf(scope, deviceTestDriverValue, deviceTestPipeconfValue))
+	assert.Equal(t, 2, requests)
+
+	err := s.validateOnosDriverAndPipeconf(scope, "stratum-tofin0", deviceTestPipeconfValue)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "driver stratum-tofin0")
+	assert.Contains(t, err.Error(), *onfSwitch.SwitchId)
+
+	err = s.validateOnosDriverAndPipeconf(scope, deviceTestDriverValue, "org.stratumproject.unknown")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "pipeconf org.stratumproject.unknown")
+
+	// an unreachable catalog does not reject the switch, but is counted and only asked for once per sync
+	s.onosCatalogs = nil
+	scope.OnosEndpoint = aStr(ts.URL + "/missing/")
+	failed := testutil.ToFloat64(KpiSynchronizationFailedTotal.WithLabelValues(deviceTestFabricID, "catalog", *scope.OnosEndpoint))
+	requests = 0
+	assert.NoError(t, s.validateOnosDriverAndPipeconf(scope, "anything", "anything"))
+	assert.NoError(t, s.validateOnosDriverAndPipeconf(scope, "anything", "anything"))
+	assert.Equal(t, 1, requests)
+	assert.True(t, s.onosCatalogFailed(scope))
+	assert.Equal(t, failed+1, testutil.ToFloat64(KpiSynchronizationFailedTotal.WithLabelValues(deviceTestFabricID, "catalog", *scope.OnosEndpoint)))
+
+	// the next sync asks again
+	s.forgetFailedOnosCatalogs()
+	assert.False(t, s.onosCatalogFailed(scope))
+	assert.NoError(t, s.validateOnosDriverAndPipeconf(scope, "anything", "anything"))
+	assert.Equal(t, 2, requests)
+
+	assert.NoError(t, testAtomix.Stop())
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// ONOS cluster support: picks a healthy cluster member to push a fabric to, failing over
+// to another member when the current one stops responding.
+
+package synchronizer
+
+import (
+	"context"
+	"fmt"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"net/http"
+	"time"
+)
+
+const (
+	// onosHealthCheckTimeout is how long a cluster member has to answer a health check
+	onosHealthCheckTimeout = 2 * time.Second
+)
+
+// onosController is a member of the ONOS cluster that controls a fabric
+type onosController struct {
+	Endpoint string // Base URI of the ONOS REST API, e.g. http://onos-1:8181/
+	Username string
+	Password string
+}
+
+// OnosHealthCheckFunc checks that a controller is able to accept pushes. Overridden by tests
+var OnosHealthCheckFunc = checkOnosHealth
+
+// newOnosControllers converts the topo controller info to controllers, dropping duplicate endpoints
+func newOnosControllers(controllerInfos []*topoapi.ControllerInfo) []*onosController {
+	controllers := []*onosController{}
+	seen := map[string]bool{}
+	for _, info := range controllerInfos {
+		uri := fmt.Sprintf("http://%s:%d/", info.ControlEndpoint.Address, info.ControlEndpoint.Port)
+		if seen[uri] {
+			continue
+		}
+		seen[uri] = true
+		controllers = append(controllers, &onosController{
+			Endpoint: uri,
+			Username: info.Username,
+			Password: info.Password,
+		})
+	}
+	return controllers
+}
+
+// checkOnosHealth asks the controller for its view of the cluster, which only succeeds if the
+// REST API is up and our credentials are accepted
+func checkOnosHealth(controller *onosController) error {
+	client := &http.Client{
+		Timeout: onosHealthCheckTimeout,
+	}
+
+	url := fmt.Sprintf("%sonos/v1/cluster", controller.Endpoint)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(controller.Username, controller.Password)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		return &PushError{Operation: "GET", Endpoint: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+// selectOnosController returns the cluster member to push a fabric to. The member that served
+// the previous push is preferred, so pushes only move to another member when it is unhealthy.
+// Members in tried have already failed a push during this sync and are skipped.
+func (s *Synchronizer) selectOnosController(fabricID string, controllers []*onosController, tried map[string]bool) (*onosController, error) {
+	if s.activeControllers == nil {
+		s.activeControllers = map[string]string{}
+	}
+
+	candidates := []*onosController{}
+	for _, controller := range controllers {
+		if tried[controller.Endpoint] {
+			continue
+		}
+		if controller.Endpoint == s.activeControllers[fabricID] {
+			candidates = append([]*onosController{controller}, candidates...)
+		} else {
+			candidates = append(candidates, controller)
+		}
+	}
+
+	for _, controller := range candidates {
+		err := OnosHealthCheckFunc(controller)
+		if err != nil {
+			log.Warnf("Fabric %s controller %s is unhealthy: %v", fabricID, controller.Endpoint, err)
+			KpiOnosControllerHealthy.WithLabelValues(fabricID, controller.Endpoint).Set(0)
+			continue
+		}
+		KpiOnosControllerHealthy.WithLabelValues(fabricID, controller.Endpoint).Set(1)
+
+		previous := s.activeControllers[fabricID]
+		if previous != controller.Endpoint {
+			if previous != "" {
+				log.Warnf("Fabric %s failing over from controller %s to %s", fabricID, previous, controller.Endpoint)
+				KpiOnosControllerFailoverTotal.WithLabelValues(fabricID).Inc()
+			}
+			s.activeControllers[fabricID] = controller.Endpoint
+		}
+		return controller, nil
+	}
+
+	if len(tried) > 0 {
+		return nil, fmt.Errorf("fabric %s has no healthy controller left out of %d", fabricID, len(controllers))
+	}
+	return nil, fmt.Errorf("fabric %s has no healthy controller out of %d", fabricID, len(controllers))
+}
+
+// pushFabricToOnosCluster renders the fabric and pushes it to a healthy member of the ONOS
+// cluster, returning the netcfg and component config push failures. When the netcfg push to a
+// member fails, the sync fails over to the next healthy member.
+func (s *Synchronizer) pushFabricToOnosCluster(ctx context.Context, scope *FabricScope, controllers []*onosController) (int, int) {
+	fabricID := *scope.FabricId
+	tried := map[string]bool{}
+	for {
+		controller, err := s.selectOnosController(fabricID, controllers, tried)
+		if err != nil {
+			// nothing to push to; count it as a push failure so that we retry later
+			log.Warnf("Failed to push fabric to ONOS %s: %v", fabricID, err)
+			return 1, 0
+		}
+		tried[controller.Endpoint] = true
+
+		log.Infof("controller uri: %s", controller.Endpoint)
+		scope.OnosEndpoint = aStr(controller.Endpoint)
+		scope.OnosUsername = aStr(controller.Username)
+		scope.OnosPassword = aStr(controller.Password)
+		scope.StratumEndpoint = aStr(controller.Endpoint)
+		// render from scratch, so nothing is left over from a push to another member
+		scope.NetConfig = &OnosNetConfig{
+			Devices: map[string]*onosDevice{},
+			Ports:   map[string]*onosPort{},
+			Hosts:   map[string]*onosHost{},
+			Apps:    map[string]*onosApp{},
+		}
+		scope.ComponentConfig = map[string]OnosComponentConfig{}
+
+		pushFailures, err := s.SynchronizeFabricToOnos(ctx, scope)
+		if err != nil {
+			// log the error and fail over to the next member
+			log.Warnf("Failed to push fabric to ONOS %s at %s: %v", fabricID, controller.Endpoint, err)
+			continue
+		}
+
+		pushComponentFailures, err := s.SynchronizeComponentConfigToOnos(scope)
+		if err != nil {
+			log.Warnf("Failed to push component config to ONOS %s: %v", fabricID, err)
+		}
+		return pushFailures, pushComponentFailures
+	}
+}
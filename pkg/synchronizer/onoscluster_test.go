@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newOnosTestServer returns an ONOS stand-in whose health is controlled by *healthy
+func newOnosTestServer(t *testing.T, healthy *bool) (*httptest.Server, *onosController) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/onos/v1/cluster", r.URL.Path)
+		if !*healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	return ts, &onosController{Endpoint: ts.URL + "/", Username: "onos", Password: "rocks"}
+}
+
+// TestControllerFailover tests that pushes stick to a healthy member and fail over when it goes down
+func TestControllerFailover(t *testing.T) {
+	const fabricID = "failover-fabric"
+	healthy1, healthy2 := false, true
+	ts1, onos1 := newOnosTestServer(t, &healthy1)
+	defer ts1.Close()
+	ts2, onos2 := newOnosTestServer(t, &healthy2)
+	defer ts2.Close()
+	controllers := []*onosController{onos1, onos2}
+
+	s := Synchronizer{}
+
+	controller, err := s.selectOnosController(fabricID, controllers, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, onos2, controller)
+	assert.Equal(t, float64(0), testutil.ToFloat64(KpiOnosControllerHealthy.WithLabelValues(fabricID, onos1.Endpoint)))
+
+	// the first member recovering does not move pushes away from the second
+	healthy1 = true
+	controller, err = s.selectOnosController(fabricID, controllers, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, onos2, controller)
+
+	failovers := testutil.ToFloat64(KpiOnosControllerFailoverTotal.WithLabelValues(fabricID))
+	healthy2 = false
+	controller, err = s.selectOnosController(fabricID, controllers, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, onos1, controller)
+	assert.Equal(t, failovers+1, testutil.ToFloat64(KpiOnosControllerFailoverTotal.WithLabelValues(fabricID)))
+
+	healthy1 = false
+	_, err = s.selectOnosController(fabricID, controllers, nil)
+	assert.EqualError(t, err, "fabric failover-fabric has no healthy controller out of 2")
+}
+
+func TestNewOnosControllers(t *testing.T) {
+	info := func(address string) *topoapi.ControllerInfo {
+		return &topoapi.ControllerInfo{
+			ControlEndpoint: &topoapi.Endpoint{Address: address, Port: 8181},
+			Username:        "onos",
+			Password:        "rocks",
+		}
+	}
+	controllers := newOnosControllers([]*topoapi.ControllerInfo{info("onos-1"), info("onos-2"), info("onos-1")})
+	assert.Len(t, controllers, 2)
+	assert.Equal(t, "http://onos-1:8181/", controllers[0].Endpoint)
+	assert.True(t, strings.HasPrefix(controllers[1].Endpoint, "http://onos-2"))
+}
+
+// TestControllerPushFailover tests that a failed netcfg push fails over to the next healthy member within the same sync
+func TestControllerPushFailover(t *testing.T) {
+	const fabricID = "push-failover-fabric"
+	newServer := func(netcfgStatus *int, pushes *int) (*httptest.Server, *onosController) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/onos/v1/cluster":
+			case "/onos/v1/applications":
+				_, _ = w.Write([]byte(`{"applications": []}`))
+			case "/onos/v1/network/configuration":
+				*pushes++
+				w.WriteHeader(*netcfgStatus)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		return ts, &onosController{Endpoint: ts.URL + "/", Username: "onos", Password: "rocks"}
+	}
+	status1, status2 := http.StatusInternalServerError, http.StatusOK
+	pushes1, pushes2 := 0, 0
+	ts1, onos1 := newServer(&status1, &pushes1)
+	defer ts1.Close()
+	ts2, onos2 := newServer(&status2, &pushes2)
+	defer ts2.Close()
+	controllers := []*onosController{onos1, onos2}
+
+	s := NewSynchronizer(WithPartialUpdateEnable(false))
+	scope := &FabricScope{
+		FabricId: aStr(fabricID),
+		Fabric:   &RootDevice{Switch: map[string]*Switch{}, DhcpServer: map[string]*DhcpServer{}},
+	}
+
+	failovers := testutil.ToFloat64(KpiOnosControllerFailoverTotal.WithLabelValues(fabricID))
+	pushFailures, componentFailures := s.pushFabricToOnosCluster(context.Background(), scope, controllers)
+	assert.Equal(t, 0, pushFailures)
+	assert.Equal(t, 0, componentFailures)
+	assert.Equal(t, 1, pushes1)
+	assert.Equal(t, 1, pushes2)
+	assert.Equal(t, onos2.Endpoint, *scope.OnosEndpoint)
+	assert.Equal(t, failovers+1, testutil.ToFloat64(KpiOnosControllerFailoverTotal.WithLabelValues(fabricID)))
+
+	// with every member failing the push, the sync counts a failure and is retried later
+	status2 = http.StatusInternalServerError
+	pushFailures, _ = s.pushFabricToOnosCluster(context.Background(), scope, controllers)
+	assert.Equal(t, 1, pushFailures)
+	assert.Equal(t, 2, pushes1)
+	assert.Equal(t, 2, pushes2)
+}
+
+// testTopoClient serves Get and List from fixed objects; List ignores its filters
+type testTopoClient struct {
+	topoapi.TopoClient
+	entities  map[string]*topoapi.Object
+	relations []topoapi.Object
+	listErr   error
+}
+
+func (c *testTopoClient) Get(ctx context.Context, in *topoapi.GetRequest, opts ...grpc.CallOption) (*topoapi.GetResponse, error) {
+	object, ok := c.entities[string(in.ID)]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%s not found", in.ID)
+	}
+	return &topoapi.GetResponse{Object: object}, nil
+}
+
+func (c *testTopoClient) List(ctx context.Context, in *topoapi.ListRequest, opts ...grpc.CallOption) (*topoapi.ListResponse, error) {
+	if c.listErr != nil {
+		return nil, c.listErr
+	}
+	return &topoapi.ListResponse{Objects: c.relations}, nil
+}
+
+// TestListFabricControllers tests which controllers of the fabric aspect and controls relations are used
+func TestListFabricControllers(t *testing.T) {
+	const fabricID = "fabric-one"
+	entity := func(id string, address string) *topoapi.Object {
+		object := &topoapi.Object{ID: topoapi.ID(id), Type: topoapi.Object_ENTITY, Obj: &topoapi.Object_Entity{Entity: &topoapi.Entity{}}}
+		if address != "" {
+			assert.NoError(t, object.SetAspect(&topoapi.ControllerInfo{ControlEndpoint: &topoapi.Endpoint{Address: address, Port: 8181}}))
+		}
+		return object
+	}
+	relation := func(kind string, src string, tgt string) topoapi.Object {
+		return topoapi.Object{Type: topoapi.Object_RELATION, Obj: &topoapi.Object_Relation{Relation: &topoapi.Relation{
+			KindID: topoapi.ID(kind), SrcEntityID: topoapi.ID(src), TgtEntityID: topoapi.ID(tgt)}}}
+	}
+	members := map[string]*topoapi.Object{
+		"onos-1":    entity("onos-1", "onos-1"),
+		"onos-2":    entity("onos-2", "onos-2"),
+		"onos-3":    entity("onos-3", "onos-3"),
+		"no-aspect": entity("no-aspect", ""),
+	}
+	allRelations := []topoapi.Object{
+		relation(topoapi.CONTROLS, "onos-2", fabricID),
+		relation(topoapi.CONTROLS, "onos-1", fabricID),
+		relation(topoapi.CONTROLS, "onos-3", "fabric-two"),
+		relation(topoapi.CONTROLS, fabricID, "onos-3"),
+		relation(topoapi.CONTAINS, "onos-3", fabricID),
+		relation(topoapi.CONTROLS, "no-aspect", fabricID),
+		relation(topoapi.CONTROLS, "missing", fabricID),
+	}
+
+	tests := map[string]struct {
+		fabricAddress string
+		relations     []topoapi.Object
+		listErr       error
+		expected      []string
+		expectedErr   bool
+	}{
+		"aspect only":                {fabricAddress: "onos-0", expected: []string{"onos-0"}},
+		"aspect then relations":      {fabricAddress: "onos-0", relations: allRelations, expected: []string{"onos-0", "onos-1", "onos-2"}},
+		"relations only":             {relations: allRelations, expected: []string{"onos-1", "onos-2"}},
+		"list error falls back":      {fabricAddress: "onos-0", relations: allRelations, listErr: errors.New("unavailable"), expected: []string{"onos-0"}},
+		"list error without aspect":  {relations: allRelations, listErr: errors.New("unavailable"), expectedErr: true},
+		"no aspect and no relations": {expectedErr: true},
+	}
+	for name, test := range tests {
+		entities := map[string]*topoapi.Object{fabricID: entity(fabricID, test.fabricAddress)}
+		for id, object := range members {
+			entities[id] = object
+		}
+		client := &testTopoClient{entities: entities, relations: test.relations, listErr: test.listErr}
+
+		controllers, err := listFabricControllers(context.Background(), client, fabricID)
+		if test.expectedErr {
+			assert.Error(t, err, name)
+			continue
+		}
+		assert.NoError(t, err, name)
+		addresses := []string{}
+		for _, controller := range controllers {
+			addresses = append(addresses, controller.ControlEndpoint.Address)
+		}
+		assert.Equal(t, test.expected, addresses, name)
+	}
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Port numbering: ONOS addresses a port by its P4Runtime port number, which Stratum takes from the
+// SingletonPort ID, so every translator must number a cage and channel the same way.
+
+package synchronizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// portNumberingAttribute is the switch model attribute that selects the port numbering scheme
+	portNumberingAttribute = "port-numbering"
+
+	// portNumberingSdFabric numbers an unchannelized cage by the cage, and a channel as
+	// cage*100+(channel-1), so that cage 2 channel 1 is port 200 (the default)
+	portNumberingSdFabric = "sdfabric"
+
+	// portNumberingSequential numbers ports consecutively from 1, allowing every cage as many
+	// ports as the largest max-channel of the model, so that with 4 channels cage 2 channel 1 is port 5
+	portNumberingSequential = "sequential"
+)
+
+var portNumberingSchemes = map[string]func(model *SwitchModel, cage uint8, channel uint8) uint32{
+	portNumberingSdFabric: func(model *SwitchModel, cage uint8, channel uint8) uint32 {
+		if channel == 0 {
+			return uint32(cage)
+		}
+		return uint32(cage)*100 + uint32(channel) - 1
+	},
+	portNumberingSequential: func(model *SwitchModel, cage uint8, channel uint8) uint32 {
+		lanes := uint32(1)
+		for _, modelPort := range model.Port {
+			if modelPort.MaxChannel != nil && uint32(*modelPort.MaxChannel) > lanes {
+				lanes = uint32(*modelPort.MaxChannel)
+			}
+		}
+		if channel == 0 {
+			channel = 1
+		}
+		return (uint32(cage)-1)*lanes + uint32(channel)
+	},
+}
+
+// portNumberingScheme returns the port numbering scheme of a switch model
+func portNumberingScheme(model *SwitchModel) string {
+	if model == nil {
+		return portNumberingSdFabric
+	}
+	if value, okay := lookupSwitchModelAttribute(model, portNumberingAttribute); okay {
+		return value
+	}
+	return portNumberingSdFabric
+}
+
+// validatePortNumbering checks that a switch model names a known port numbering scheme
+func validatePortNumbering(model *SwitchModel) error {
+	scheme := portNumberingScheme(model)
+	if _, okay := portNumberingSchemes[scheme]; !okay {
+		schemes := []string{}
+		for name := range portNumberingSchemes {
+			schemes = append(schemes, name)
+		}
+		sort.Strings(schemes)
+		return fmt.Errorf("SwitchModel port-numbering %s is not one of %s", scheme, strings.Join(schemes, ", "))
+	}
+	return nil
+}
+
+// portNumber returns the number of a cage and channel, used for the Stratum SingletonPort ID and
+// the ONOS port alike. A nil model or a model that fails validatePortNumbering uses the default.
+func portNumber(model *SwitchModel, cage uint8, channel uint8) uint32 {
+	scheme, okay := portNumberingSchemes[portNumberingScheme(model)]
+	if !okay {
+		scheme = portNumberingSchemes[portNumberingSdFabric]
+	}
+	return scheme(model, cage, channel)
+}
+
+// onosPortID returns the ONOS "device:<switch>/<port>" ID of a switch port
+func onosPortID(sw *Switch, model *SwitchModel, p *Port) string {
+	return fmt.Sprintf("device:%s/%d", *sw.SwitchId, portNumber(model, *p.CageNumber, *p.ChannelNumber))
+}
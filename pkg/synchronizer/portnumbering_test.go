@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/onosproject/config-models/models/sdn-fabric-0.1.x/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// portNumberingTestModel makes a model of 32 cages, each broken out into up to maxChannel channels
+func portNumberingTestModel(scheme string, maxChannel uint8) *SwitchModel {
+	model := &SwitchModel{Port: map[uint8]*api.OnfSwitchModel_SwitchModel_Port{}}
+	for cage := uint8(1); cage <= 32; cage++ {
+		model.Port[cage] = &api.OnfSwitchModel_SwitchModel_Port{CageNumber: aUint8(cage), MaxChannel: aUint8(maxChannel)}
+	}
+	if scheme != "" {
+		addModelAttribute(model, portNumberingAttribute, scheme)
+	}
+	return model
+}
+
+func TestPortNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   *SwitchModel
+		cage    uint8
+		channel uint8
+		port    uint32
+	}{
+		{name: "no model unchannelized", model: nil, cage: 3, channel: 0, port: 3},
+		{name: "no model channelized", model: nil, cage: 2, channel: 2, port: 201},
+		{name: "default unchannelized", model: portNumberingTestModel("", 4), cage: 32, channel: 0, port: 32},
+		{name: "default first channel", model: portNumberingTestModel("", 4), cage: 2, channel: 1, port: 200},
+		{name: "default last channel", model: portNumberingTestModel("", 4), cage: 32, channel: 4, port: 3203},
+		{name: "sdfabric unchannelized", model: portNumberingTestModel(portNumberingSdFabric, 4), cage: 1, channel: 0, port: 1},
+		{name: "sdfabric channelized", model: portNumberingTestModel(portNumberingSdFabric, 4), cage: 1, channel: 3, port: 102},
+		{name: "sequential unchannelized first cage", model: portNumberingTestModel(portNumberingSequential, 4), cage: 1, channel: 0, port: 1},
+		{name: "sequential unchannelized", model: portNumberingTestModel(portNumberingSequential, 4), cage: 3, channel: 0, port: 9},
+		{name: "sequential first channel", model: portNumberingTestModel(portNumberingSequential, 4), cage: 3, channel: 1, port: 9},
+		{name: "sequential last channel", model: portNumberingTestModel(portNumberingSequential, 4), cage: 3, channel: 4, port: 12},
+		{name: "sequential 8 channels", model: portNumberingTestModel(portNumberingSequential, 8), cage: 2, channel: 8, port: 16},
+		{name: "sequential no breakout", model: portNumberingTestModel(portNumberingSequential, 0), cage: 5, channel: 0, port: 5},
+		{name: "unknown scheme falls back", model: portNumberingTestModel("bogus", 4), cage: 2, channel: 2, port: 201},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.port, portNumber(test.model, test.cage, test.channel))
+		})
+	}
+}
+
+func TestOnosPortID(t *testing.T) {
+	sw := &Switch{SwitchId: aStr("leaf-1")}
+	p := &Port{CageNumber: aUint8(3), ChannelNumber: aUint8(2)}
+
+	assert.Equal(t, "device:leaf-1/301", onosPortID(sw, nil, p))
+	assert.Equal(t, "device:leaf-1/10", onosPortID(sw, portNumberingTestModel(portNumberingSequential, 4), p))
+
+	cp := &connectPoint{Switch: sw, Model: portNumberingTestModel(portNumberingSequential, 4), Port: p}
+	assert.Equal(t, "device:leaf-1/10", cp.String())
+}
+
+func TestValidatePortNumbering(t *testing.T) {
+	assert.NoError(t, validatePortNumbering(nil))
+	assert.NoError(t, validatePortNumbering(portNumberingTestModel("", 4)))
+	assert.NoError(t, validatePortNumbering(portNumberingTestModel(portNumberingSequential, 4)))
+	assert.EqualError(t, validatePortNumbering(portNumberingTestModel("bogus", 4)),
+		fmt.Sprintf("SwitchModel port-numbering bogus is not one of %s, %s", portNumberingSdFabric, portNumberingSequential))
+}
+
+// TestPortNumberingTranslators tests that the ONOS and Stratum translators number ports alike
+func TestPortNumberingTranslators(t *testing.T) {
+	s := Synchronizer{}
+	scope, sw, _ := newAppsScope()
+	for _, modelPort := range scope.SwitchModel.Port {
+		modelPort.MaxChannel = aUint8(4)
+	}
+	addModelAttribute(scope.SwitchModel, portNumberingAttribute, portNumberingSequential)
+
+	for _, key := range sortedPortKeys(sw) {
+		assert.NoError(t, s.handleSwitchPort(scope, sw.Port[key]))
+	}
+	assert.NoError(t, s.handleStratumSwitch(scope))
+
+	assert.NotEmpty(t, scope.StratumChassisConfig.SingletonPorts)
+	for _, singletonPort := range scope.StratumChassisConfig.SingletonPorts {
+		assert.Contains(t, scope.NetConfig.Ports, fmt.Sprintf("device:%s/%d", deviceTestLeafID, singletonPort.Id))
+	}
+	// port 2/2 is the second channel of the second cage
+	assert.Contains(t, scope.NetConfig.Ports, "device:"+deviceTestLeafID+"/6")
+
+	addModelAttribute(scope.SwitchModel, portNumberingAttribute, "bogus")
+	assert.EqualError(t, s.handleStratumSwitch(scope), fmt.Sprintf("fabric %s switch %s: SwitchModel port-numbering bogus is not one of %s, %s",
+		deviceTestFabricID, deviceTestLeafID, portNumberingSdFabric, portNumberingSequential))
+}
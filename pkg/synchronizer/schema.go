@@ -7,7 +7,78 @@
 package synchronizer
 
 const (
-	onosRouteAppName = "org.onosproject.route-service"
+	onosRouteAppName     = "org.onosproject.route-service"
+	onosDhcpRelayAppName = "org.onosproject.dhcprelay"
+	onosUp4AppName       = "org.omecproject.up4"
+	onosIntAppName       = "org.stratumproject.fabric.tna.inbandtelemetry"
+
+	// hostAttributePrefix prefixes the switch attributes that describe static hosts
+	hostAttributePrefix = "host"
+
+	// platformAttribute is the switch model attribute that names the Stratum platform
+	platformAttribute = "platform"
+
+	// chassisNodeAttributePrefix prefixes the switch model attributes that describe Stratum nodes
+	chassisNodeAttributePrefix = "node"
+
+	// portAttributePrefix prefixes the switch attributes that set port parameters, as
+	// port.<cage>/<channel>.<parameter>
+	portAttributePrefix = "port"
+
+	// minPortMtu is the smallest MTU accepted for a port, the IPv4 minimum
+	minPortMtu = 576
+
+	// defaultPortMtu is the MTU of a port without an mtu parameter
+	defaultPortMtu = 1500
+
+	// qosPoolAttributePrefix and qosQueueAttributePrefix prefix the switch and switch model
+	// attributes of the Tofino QoS profile, as qos-pool.<pool>.<field> and qos-queue.<id>.<field>
+	qosPoolAttributePrefix  = "qos-pool"
+	qosQueueAttributePrefix = "qos-queue"
+
+	// maxQosQueueID is the highest queue of a Tofino port
+	maxQosQueueID = 31
+
+	// defaultShapingBurstBytes is the burst allowed by port shaping and queue rates when not set
+	defaultShapingBurstBytes = 16384
+
+	// trunkAttributePrefix prefixes the switch attributes that group ports into trunks
+	trunkAttributePrefix = "trunk"
+
+	// lacpSystemPriorityAttribute is the switch attribute holding the LACP system priority
+	lacpSystemPriorityAttribute = "lacp-system-priority"
+
+	// defaultLacpSystemPriority is the LACP system priority used when the attribute is not set
+	defaultLacpSystemPriority = 32768
+
+	// xconnectAttributePrefix prefixes the switch attributes that describe cross-connects
+	xconnectAttributePrefix = "xconnect"
+
+	// onosLocTypeGrid places devices on the ONOS GUI grid using gridX and gridY
+	onosLocTypeGrid = "grid"
+
+	// gridColumnSpacing is the horizontal distance between switches in the default layout
+	gridColumnSpacing = 100
+
+	// gridSpineRow, gridLeafRow and gridOtherRow are the rows of the default layout
+	gridSpineRow = 100
+	gridLeafRow  = 300
+	gridOtherRow = 500
+
+	// componentAttributePrefix prefixes the switch attributes that set onos component properties
+	componentAttributePrefix = "component"
+
+	// telemetryAttributePrefix prefixes the switch attributes that describe INT reporting
+	telemetryAttributePrefix = "int"
+
+	// defaultTelemetryCollectorPort is the UDP port INT reports are sent to if none is given
+	defaultTelemetryCollectorPort = 32766
+
+	// defaultTelemetryMinFlowHopLatencyChangeNs is the hop latency change that triggers a flow report
+	defaultTelemetryMinFlowHopLatencyChangeNs = 256
+
+	// maxTelemetryQueueID is the highest egress queue a latency threshold can be set for
+	maxTelemetryQueueID = 31
 )
 
 type onosDevice struct {
@@ -17,17 +88,17 @@ type onosDevice struct {
 		RouterMac     string   `json:"routerMac,omitempty"`
 		IsEdgeRouter  bool     `json:"isEdgeRouter"`
 		PairDeviceID  string   `json:"pairDeviceId,omitempty"`
-		PairLocalPort uint16   `json:"pairLocalPort,omitempty"`
+		PairLocalPort uint32   `json:"pairLocalPort,omitempty"`
 		AdjacencySids []uint16 `json:"adjacencySids"`
 	} `json:"segmentrouting"`
 	Basic struct {
-		Name              string `json:"name"`
-		ManagementAddress string `json:"managementAddress,omitempty"`
-		Driver            string `json:"driver"`
-		PipeConf          string `json:"pipeconf"`
-		LocType           string `json:"locType,omitempty"`
-		GridX             uint16 `json:"gridX,omitempty"`
-		GridY             uint16 `json:"gridY,omitempty"`
+		Name              string  `json:"name"`
+		ManagementAddress string  `json:"managementAddress,omitempty"`
+		Driver            string  `json:"driver"`
+		PipeConf          string  `json:"pipeconf"`
+		LocType           string  `json:"locType,omitempty"`
+		GridX             *uint16 `json:"gridX,omitempty"`
+		GridY             *uint16 `json:"gridY,omitempty"`
 	} `json:"basic"`
 }
 
@@ -40,6 +111,9 @@ type onosInterface struct {
 
 type onosPort struct {
 	Interfaces []onosInterface `json:"interfaces"`
+	Basic      struct {
+		Enabled bool `json:"enabled"`
+	} `json:"basic"`
 }
 
 type onosHost struct {
@@ -64,26 +138,33 @@ type onosDhcpConfig struct {
 	ServerIps    []string `json:"serverIps"`
 }
 
-type onosTelemetryReport struct {
-	CollectorIP               string   `json:"collectorIp"`
-	CollectorPort             uint16   `json:"collectorPort"`
-	MinFlowHopLatencyChangeNs uint32   `json:"minFlowHopLatencyChangeNs"`
-	WatchSubnets              []string `json:"watchSubnets"`
-}
-
 type onosTelemetryQueue struct {
 	TriggerNs uint32 `json:"triggerNs"`
-	ResetNs   uint32 `json:"restNs"`
+	ResetNs   uint32 `json:"resetNs,omitempty"`
+}
+
+type onosTelemetryReport struct {
+	CollectorIP                  string                        `json:"collectorIp"`
+	CollectorPort                uint16                        `json:"collectorPort"`
+	MinFlowHopLatencyChangeNs    uint32                        `json:"minFlowHopLatencyChangeNs"`
+	WatchSubnets                 []string                      `json:"watchSubnets"`
+	QueueReportLatencyThresholds map[string]onosTelemetryQueue `json:"queueReportLatencyThresholds,omitempty"`
 }
 
 // Note: These are probably app-specific and should be
 // broken out into a union of independent configs
 type onosApp struct {
-	Routes                                []onosRoute                   `json:"routes,omitempty"`
-	Up4                                   *onosUp4Config                `json:"up4,omitempty"`
-	DhcpDefault                           *onosDhcpConfig               `json:"default,omitempty"`
-	TelemetryReport                       *onosTelemetryReport          `json:"report,omitempty"`
-	TelemetryQueueReportLatencyThresholds map[string]onosTelemetryQueue `json:"queueReportLatencyThresholds,omitempty"`
+	Routes          []onosRoute               `json:"routes,omitempty"`
+	Up4             *onosUp4Config            `json:"up4,omitempty"`
+	DhcpDefault     []onosDhcpConfig          `json:"default,omitempty"`
+	TelemetryReport *onosTelemetryReport      `json:"report,omitempty"`
+	Xconnect        map[string][]onosXconnect `json:"xconnect,omitempty"`
+}
+
+type onosXconnect struct {
+	Name  string   `json:"name"`
+	Vlan  uint16   `json:"vlan"`
+	Ports []uint32 `json:"ports"`
 }
 
 // OnosNetConfig JSON Schema for an onos netcfg
@@ -94,13 +175,10 @@ type OnosNetConfig struct {
 	Apps    map[string]*onosApp    `json:"apps,omitempty"`
 }
 
-// OnosComponentConfig JSON Schema for an onos component config
-// NOTE: These are probably component-specific
-type OnosComponentConfig struct {
-	MonitorHosts bool   `json:"monitorHosts"`
-	OrobeRate    string `json:"probeRate"`
-	RealPortID   bool   `json:"realPortId"`
-}
+// OnosComponentConfig JSON Schema for an onos component config. The properties are
+// component-specific (e.g. monitorHosts, probeRate and realPortId for HostProbingProvider),
+// so they are kept as a map of property name to value.
+type OnosComponentConfig map[string]string
 
 // OnosConfig JSON Schema for an onos config
 type OnosConfig struct {
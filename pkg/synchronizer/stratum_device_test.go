@@ -6,10 +6,17 @@
 package synchronizer
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/gogo/protobuf/proto"
 	"github.com/onosproject/config-models/models/sdn-fabric-0.1.x/api"
 	"github.com/onosproject/fabric-adapter/pkg/stratum_hal"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -178,3 +185,467 @@ func TestGNMI(t *testing.T) {
 
 	assert.NoError(t, testAtomix.Stop())
 }
+
+// TestPortAdminState tests that an administratively disabled port is disabled in both ONOS and Stratum
+func TestPortAdminState(t *testing.T) {
+	s := Synchronizer{}
+	scope, _, port := newAppsScope()
+
+	assert.NoError(t, s.handleSwitchPort(scope, port))
+	assert.True(t, scope.NetConfig.Ports["device:"+deviceTestLeafID+"/201"].Basic.Enabled)
+
+	port.State = &api.OnfSwitch_Switch_Port_State{AdminStatus: PortAdminStatusDown}
+	assert.NoError(t, s.handleSwitchPort(scope, port))
+	assert.False(t, scope.NetConfig.Ports["device:"+deviceTestLeafID+"/201"].Basic.Enabled)
+
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, stratum_hal.AdminState_ADMIN_STATE_DISABLED, findPort(t, 201, *scope).ConfigParams.AdminState)
+
+	port.State.AdminStatus = PortAdminStatusTesting
+	scope.StratumChassisConfig.SingletonPorts = nil
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, stratum_hal.AdminState_ADMIN_STATE_DIAG, findPort(t, 201, *scope).ConfigParams.AdminState)
+}
+
+func addModelAttribute(model *SwitchModel, key string, value string) {
+	if model.Attribute == nil {
+		model.Attribute = map[string]*api.OnfSwitchModel_SwitchModel_Attribute{}
+	}
+	model.Attribute[key] = &api.OnfSwitchModel_SwitchModel_Attribute{
+		AttributeKey: aStr(key),
+		Value:        aStr(value),
+	}
+}
+
+// TestChassisNodes tests assignment of ports to the slots and nodes of a chassis switch
+func TestChassisNodes(t *testing.T) {
+	testAtomix, sidStore := getAtomixStore(t)
+	s := Synchronizer{sidStore: sidStore}
+	scope, onfSwitch, _ := newAppsScope()
+	addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 12}, 12, 0, "port12", "Port 12", api.OnfSdnFabricTypes_Speed_speed_100g)
+
+	addModelAttribute(scope.SwitchModel, "node.3.cages", "1-8")
+	addModelAttribute(scope.SwitchModel, "node.4.slot", "2")
+	addModelAttribute(scope.SwitchModel, "node.4.cages", "9-16")
+
+	nodes, byCage, err := lookupChassisNodes(scope.SwitchModel)
+	assert.NoError(t, err)
+	assert.Equal(t, []*stratum_hal.Node{{Id: 3, Slot: 1, Index: 1}, {Id: 4, Slot: 2, Index: 1}}, nodes)
+	assert.Equal(t, uint64(3), byCage[2].Id)
+	assert.Equal(t, uint64(4), byCage[12].Id)
+
+	// ONOS manages a switch as a single device, so both sides reject a multi node model alike
+	expected := "fabric fabric-one switch leaf-one: SwitchModel has 2 nodes, but ONOS manages a switch as a single device"
+	assert.EqualError(t, s.handleStratumSwitch(scope), expected)
+	assert.EqualError(t, s.handleSwitch(context.Background(), scope), expected)
+	assert.Empty(t, scope.NetConfig.Devices)
+
+	// a cage may only be on one node
+	addModelAttribute(scope.SwitchModel, "node.4.cages", "8-16")
+	_, _, err = lookupChassisNodes(scope.SwitchModel)
+	assert.Error(t, err)
+
+	// nodes must be at different positions
+	addModelAttribute(scope.SwitchModel, "node.4.cages", "9-16")
+	addModelAttribute(scope.SwitchModel, "node.4.slot", "1")
+	_, _, err = lookupChassisNodes(scope.SwitchModel)
+	assert.Error(t, err)
+
+	// cages must be in the model
+	addModelAttribute(scope.SwitchModel, "node.4.slot", "2")
+	addModelAttribute(scope.SwitchModel, "node.4.cages", "9-17")
+	_, _, err = lookupChassisNodes(scope.SwitchModel)
+	assert.Error(t, err)
+
+	// a single node in another slot, which ONOS addresses by its id
+	delete(scope.SwitchModel.Attribute, "node.4.slot")
+	delete(scope.SwitchModel.Attribute, "node.4.cages")
+	addModelAttribute(scope.SwitchModel, "node.3.slot", "2")
+	addModelAttribute(scope.SwitchModel, "node.3.cages", "1-16")
+	scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, []*stratum_hal.Node{{Id: 3, Slot: 2, Index: 1}}, scope.StratumChassisConfig.Nodes)
+	port12 := findPort(t, 12, *scope)
+	assert.Equal(t, int32(2), port12.Slot)
+	assert.Equal(t, uint64(3), port12.Node)
+
+	assert.NoError(t, s.handleSwitch(context.Background(), scope))
+	assert.Equal(t, getStratumEndpointForNetcfg(deviceTestLeafManagementIP, deviceTestLeafManagementPort, 3),
+		scope.NetConfig.Devices["device:"+deviceTestLeafID].Basic.ManagementAddress)
+	assert.True(t, strings.HasSuffix(scope.NetConfig.Devices["device:"+deviceTestLeafID].Basic.ManagementAddress, "?device_id=3"))
+
+	assert.NoError(t, testAtomix.Stop())
+}
+
+// TestChassisPlatform tests selection of the Stratum platform from the switch model
+func TestChassisPlatform(t *testing.T) {
+	s := Synchronizer{}
+	scope, _, _ := newAppsScope()
+
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, stratum_hal.Platform_PLT_GENERIC_BAREFOOT_TOFINO, scope.StratumChassisConfig.Chassis.Platform)
+
+	addModelAttribute(scope.SwitchModel, "platform", "generic_barefoot_tofino2")
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, stratum_hal.Platform_PLT_GENERIC_BAREFOOT_TOFINO2, scope.StratumChassisConfig.Chassis.Platform)
+
+	addModelAttribute(scope.SwitchModel, "platform", "PLT_GENERIC_TOMAHAWK3")
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, stratum_hal.Platform_PLT_GENERIC_TOMAHAWK3, scope.StratumChassisConfig.Chassis.Platform)
+
+	addModelAttribute(scope.SwitchModel, "platform", "PLT_UNKNOWN")
+	assert.Error(t, s.handleStratumSwitch(scope))
+
+	addModelAttribute(scope.SwitchModel, "platform", "tofino3")
+	err := s.handleStratumSwitch(scope)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), deviceTestLeafID)
+}
+
+// TestTrunks tests grouping of ports into trunks for both Stratum and ONOS
+func TestTrunks(t *testing.T) {
+	testAtomix, sidStore := getAtomixStore(t)
+	s := Synchronizer{sidStore: sidStore}
+	scope, onfSwitch, port22 := newAppsScope()
+	port30 := addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 3}, 3, 0, "port3", "Port 3", api.OnfSdnFabricTypes_Speed_speed_10g)
+	port30.Vlans = port22.Vlans
+	addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 4}, 4, 0, "port4", "Port 4", api.OnfSdnFabricTypes_Speed_speed_10g)
+
+	addAttribute(onfSwitch, "trunk.1000.members", "3/0,2/2")
+	addAttribute(onfSwitch, "trunk.1000.name", "bond0")
+	addAttribute(onfSwitch, "trunk.1001.members", "4/0")
+	addAttribute(onfSwitch, "trunk.1001.type", "static")
+	// member vlans differ
+	addAttribute(onfSwitch, "trunk.1002.members", "3/0,4/0")
+	// 3/0 is already in trunk 1000
+	addAttribute(onfSwitch, "trunk.1003.members", "3/0")
+	// id of port 3/0
+	addAttribute(onfSwitch, "trunk.3.members", "4/0")
+	addAttribute(onfSwitch, "lacp-system-priority", "100")
+
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, []*stratum_hal.TrunkPort{
+		{Id: 1000, Name: "bond0", Node: 1, Type: stratum_hal.TrunkPort_LACP_TRUNK, Members: []uint32{3, 201},
+			ConfigParams: &stratum_hal.PortConfigParams{AdminState: stratum_hal.AdminState_ADMIN_STATE_ENABLED}},
+		{Id: 1001, Name: "trunk-1001", Node: 1, Type: stratum_hal.TrunkPort_STATIC_TRUNK, Members: []uint32{4},
+			ConfigParams: &stratum_hal.PortConfigParams{AdminState: stratum_hal.AdminState_ADMIN_STATE_ENABLED}},
+	}, scope.StratumChassisConfig.TrunkPorts)
+	assert.Equal(t, &stratum_hal.ChassisConfigParams_LacpConfig{LacpRouterMac: 0x0B16212C, LacpSystemPriority: 100},
+		scope.StratumChassisConfig.Chassis.ConfigParams.LacpConfig)
+
+	assert.NoError(t, s.handleSwitch(context.Background(), scope))
+	trunk, ok := scope.NetConfig.Ports["device:"+deviceTestLeafID+"/1000"]
+	assert.True(t, ok)
+	assert.Len(t, trunk.Interfaces, 1)
+	assert.Equal(t, "bond0", trunk.Interfaces[0].Name)
+	assert.Equal(t, uint16(55), trunk.Interfaces[0].VlanUntagged)
+	assert.True(t, trunk.Basic.Enabled)
+	assert.NotContains(t, scope.NetConfig.Ports, "device:"+deviceTestLeafID+"/201")
+	assert.NotContains(t, scope.NetConfig.Ports, "device:"+deviceTestLeafID+"/3")
+	assert.Contains(t, scope.NetConfig.Ports, "device:"+deviceTestLeafID+"/1001")
+	assert.NotContains(t, scope.NetConfig.Ports, "device:"+deviceTestLeafID+"/1003")
+
+	// the LACP system MAC is the router MAC given to ONOS
+	routerMac, err := macToUint64(scope.NetConfig.Devices["device:"+deviceTestLeafID].SegmentRouting.RouterMac)
+	assert.NoError(t, err)
+	assert.Equal(t, routerMac, scope.StratumChassisConfig.Chassis.ConfigParams.LacpConfig.LacpRouterMac)
+
+	// a trunk is enabled while any of its members is
+	port30.State = &api.OnfSwitch_Switch_Port_State{AdminStatus: PortAdminStatusDown}
+	scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, stratum_hal.AdminState_ADMIN_STATE_ENABLED, scope.StratumChassisConfig.TrunkPorts[0].ConfigParams.AdminState)
+	assert.NoError(t, s.handleSwitch(context.Background(), scope))
+	assert.True(t, scope.NetConfig.Ports["device:"+deviceTestLeafID+"/1000"].Basic.Enabled)
+
+	// and disabled once all of them are
+	port22.State = &api.OnfSwitch_Switch_Port_State{AdminStatus: PortAdminStatusDown}
+	scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Equal(t, stratum_hal.AdminState_ADMIN_STATE_DISABLED, scope.StratumChassisConfig.TrunkPorts[0].ConfigParams.AdminState)
+	assert.Equal(t, stratum_hal.AdminState_ADMIN_STATE_ENABLED, scope.StratumChassisConfig.TrunkPorts[1].ConfigParams.AdminState)
+	assert.NoError(t, s.handleSwitch(context.Background(), scope))
+	assert.False(t, scope.NetConfig.Ports["device:"+deviceTestLeafID+"/1000"].Basic.Enabled)
+	assert.True(t, scope.NetConfig.Ports["device:"+deviceTestLeafID+"/1001"].Basic.Enabled)
+	port30.State = nil
+	port22.State = nil
+
+	addAttribute(onfSwitch, "lacp-system-priority", "65536")
+	assert.Error(t, s.handleStratumSwitch(scope))
+
+	// a hostname has no MAC to derive
+	addAttribute(onfSwitch, "lacp-system-priority", "100")
+	onfSwitch.Management.Address = aStr("leaf-one.example.com")
+	scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+	assert.Error(t, s.handleStratumSwitch(scope))
+	// ONOS still gets the switch
+	assert.NoError(t, s.handleSwitch(context.Background(), scope))
+	onfSwitch.Management.Address = &deviceTestLeafManagementIP
+
+	assert.NoError(t, testAtomix.Stop())
+}
+
+// TestPortCapabilities tests that ports the switch model cannot support are left out of the stratum config
+func TestPortCapabilities(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, _ := newAppsScope()
+	for cage := uint8(5); cage <= 9; cage++ {
+		scope.SwitchModel.Port[cage].MaxChannel = aUint8(4)
+		scope.SwitchModel.Port[cage].Speeds = []api.E_OnfSdnFabricTypes_Speed{
+			api.OnfSdnFabricTypes_Speed_speed_100g,
+			api.OnfSdnFabricTypes_Speed_speed_25g,
+			api.OnfSdnFabricTypes_Speed_speed_10g,
+		}
+	}
+	addPort := func(cage uint8, channel uint8, speed api.E_OnfSdnFabricTypes_Speed) {
+		addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: cage, ChannelNumber: channel}, cage, channel,
+			fmt.Sprintf("port %d/%d", cage, channel), fmt.Sprintf("Port %d/%d", cage, channel), speed)
+	}
+	// 4x25G fits a 100G cage
+	for channel := uint8(1); channel <= 4; channel++ {
+		addPort(5, channel, api.OnfSdnFabricTypes_Speed_speed_25g)
+	}
+	// only 4 channels
+	addPort(6, 5, api.OnfSdnFabricTypes_Speed_speed_10g)
+	// 4x100G does not fit a 100G cage
+	for channel := uint8(1); channel <= 4; channel++ {
+		addPort(7, channel, api.OnfSdnFabricTypes_Speed_speed_100g)
+	}
+	// 40G is not supported
+	addPort(8, 0, api.OnfSdnFabricTypes_Speed_speed_40g)
+	// cage used whole and broken out
+	addPort(9, 0, api.OnfSdnFabricTypes_Speed_speed_10g)
+	addPort(9, 1, api.OnfSdnFabricTypes_Speed_speed_10g)
+
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	ids := []uint32{}
+	for _, port := range scope.StratumChassisConfig.SingletonPorts {
+		ids = append(ids, port.Id)
+	}
+	assert.ElementsMatch(t, []uint32{201, 500, 501, 502, 503}, ids)
+}
+
+// TestPortParameters tests FEC, MTU and loopback defaults and their port and switch model overrides
+func TestPortParameters(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, _ := newAppsScope()
+	addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 3}, 3, 0, "port3", "Port 3", api.OnfSdnFabricTypes_Speed_speed_100g)
+	addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 4}, 4, 0, "port4", "Port 4", api.OnfSdnFabricTypes_Speed_speed_25g)
+	addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 5}, 5, 0, "port5", "Port 5", api.OnfSdnFabricTypes_Speed_speed_100g)
+
+	// the per-speed defaults
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	params := findPort(t, 201, *scope).ConfigParams
+	assert.Equal(t, stratum_hal.FecMode_FEC_MODE_UNKNOWN, params.FecMode)
+	assert.Equal(t, int32(defaultPortMtu), params.Mtu)
+	for _, id := range []uint32{3, 4, 5} {
+		params = findPort(t, id, *scope).ConfigParams
+		assert.Equal(t, stratum_hal.FecMode_FEC_MODE_ON, params.FecMode)
+		assert.Equal(t, int32(defaultPortMtu), params.Mtu)
+	}
+
+	addModelAttribute(scope.SwitchModel, "mtu", "9000")
+	addModelAttribute(scope.SwitchModel, "fec.speed-25g", "off")
+	addAttribute(onfSwitch, "port.3/0.mtu", "1400")
+	addAttribute(onfSwitch, "port.3/0.loopback", "mac")
+	addAttribute(onfSwitch, "port.3/0.fec", "off")
+	addAttribute(onfSwitch, "port.5/0.fec", "rs")
+
+	scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Len(t, scope.StratumChassisConfig.SingletonPorts, 3)
+
+	params = findPort(t, 201, *scope).ConfigParams
+	assert.Equal(t, stratum_hal.FecMode_FEC_MODE_UNKNOWN, params.FecMode)
+	assert.Equal(t, int32(9000), params.Mtu)
+	assert.Equal(t, stratum_hal.LoopbackState_LOOPBACK_STATE_UNKNOWN, params.LoopbackMode)
+
+	params = findPort(t, 3, *scope).ConfigParams
+	assert.Equal(t, stratum_hal.FecMode_FEC_MODE_OFF, params.FecMode)
+	assert.Equal(t, int32(1400), params.Mtu)
+	assert.Equal(t, stratum_hal.LoopbackState_LOOPBACK_STATE_MAC, params.LoopbackMode)
+
+	params = findPort(t, 4, *scope).ConfigParams
+	assert.Equal(t, stratum_hal.FecMode_FEC_MODE_OFF, params.FecMode)
+	assert.Equal(t, int32(9000), params.Mtu)
+}
+
+// TestTofinoVendorConfig tests translation of the QoS profile and port shaping to the Tofino vendor config
+func TestTofinoVendorConfig(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, _ := newAppsScope()
+	addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 3}, 3, 0, "port3", "Port 3", api.OnfSdnFabricTypes_Speed_speed_100g)
+
+	addModelAttribute(scope.SwitchModel, "qos-pool.egress-0.size", "1000")
+	addModelAttribute(scope.SwitchModel, "qos-queue.0.priority", "0")
+	addAttribute(onfSwitch, "qos-pool.egress-0.color-drop", "true")
+	addAttribute(onfSwitch, "qos-queue.1.priority", "7")
+	addAttribute(onfSwitch, "qos-queue.1.pool", "egress-0")
+	addAttribute(onfSwitch, "qos-queue.1.max-rate-bps", "1000000000")
+	addAttribute(onfSwitch, "port.3/0.shaping-rate-bps", "40000000000")
+
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.NotNil(t, scope.StratumChassisConfig.VendorConfig)
+	tofino := scope.StratumChassisConfig.VendorConfig.TofinoConfig
+
+	qos := tofino.NodeIdToQosConfig[1]
+	assert.Equal(t, []*stratum_hal.TofinoConfig_TofinoQosConfig_PoolConfig{
+		{Pool: stratum_hal.TofinoConfig_TofinoQosConfig_EGRESS_APP_POOL_0, PoolSize: 1000, EnableColorDrop: true},
+	}, qos.PoolConfigs)
+	assert.Len(t, qos.QueueConfigs, 2)
+	assert.Equal(t, uint32(3), qos.QueueConfigs[0].GetPort())
+	assert.Equal(t, uint32(201), qos.QueueConfigs[1].GetPort())
+	mappings := qos.QueueConfigs[0].QueueMapping
+	assert.Len(t, mappings, 2)
+	assert.Equal(t, stratum_hal.TofinoConfig_TofinoQosConfig_PRIO_0, mappings[0].Priority)
+	assert.Equal(t, stratum_hal.TofinoConfig_TofinoQosConfig_PRIO_7, mappings[1].Priority)
+	assert.Equal(t, stratum_hal.TofinoConfig_TofinoQosConfig_EGRESS_APP_POOL_0, mappings[1].Pool)
+	assert.Equal(t, uint64(1000000000), mappings[1].GetMaxRateBytes().RateBps)
+
+	shaping := tofino.NodeIdToPortShapingConfig[1].PerPortShapingConfigs
+	assert.Len(t, shaping, 1)
+	assert.Equal(t, &stratum_hal.TofinoConfig_ByteShape{RateBps: 40000000000, BurstBytes: defaultShapingBurstBytes}, shaping[3].GetByteShaping())
+
+	var text bytes.Buffer
+	assert.NoError(t, proto.MarshalText(&text, &scope.StratumChassisConfig))
+	assert.Contains(t, text.String(), "max_rate_bytes")
+
+	invalid := map[string]string{
+		"qos-queue.2.pool":       "egress-1",
+		"qos-queue.3.pool":       "ingress-0",
+		"qos-queue.40.priority":  "1",
+		"qos-queue.4.priority":   "8",
+		"qos-pool.egress-9.size": "10",
+	}
+	for key, value := range invalid {
+		scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+		addAttribute(onfSwitch, key, value)
+		assert.Error(t, s.handleStratumSwitch(scope), key)
+		delete(onfSwitch.Attribute, key)
+	}
+
+	// QoS can not be applied to other platforms
+	scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+	addModelAttribute(scope.SwitchModel, "platform", "PLT_GENERIC_TOMAHAWK")
+	assert.Error(t, s.handleStratumSwitch(scope))
+
+	for key := range scope.SwitchModel.Attribute {
+		if strings.HasPrefix(key, qosPoolAttributePrefix) || strings.HasPrefix(key, qosQueueAttributePrefix) {
+			delete(scope.SwitchModel.Attribute, key)
+		}
+	}
+	for key := range onfSwitch.Attribute {
+		if strings.HasPrefix(key, qosPoolAttributePrefix) || strings.HasPrefix(key, qosQueueAttributePrefix) {
+			delete(onfSwitch.Attribute, key)
+		}
+	}
+	delete(onfSwitch.Attribute, "port.3/0.shaping-rate-bps")
+	scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	assert.Nil(t, scope.StratumChassisConfig.VendorConfig)
+}
+
+// TestValidateChassisConfig tests that structural mistakes in a rendered ChassisConfig are found before it is pushed
+func TestValidateChassisConfig(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, port22 := newAppsScope()
+	port30 := addNewPort(onfSwitch, api.OnfSwitch_Switch_Port_Key{CageNumber: 3}, 3, 0, "port3", "Port 3", api.OnfSdnFabricTypes_Speed_speed_10g)
+	port30.Vlans = port22.Vlans
+	addAttribute(onfSwitch, "trunk.7.members", "2/2,3/0")
+
+	render := func() *stratum_hal.ChassisConfig {
+		scope.StratumChassisConfig = stratum_hal.ChassisConfig{}
+		assert.NoError(t, s.handleStratumSwitch(scope))
+		return &scope.StratumChassisConfig
+	}
+	assert.NoError(t, validateChassisConfig(onfSwitch, render()))
+
+	invalid := map[string]func(config *stratum_hal.ChassisConfig){
+		"port 3/0 (id 3) has the same id as port 2/2 (id 3)":     func(config *stratum_hal.ChassisConfig) { findPort(t, 201, *scope).Id = 3 },
+		"port 2/2 (id 201) has speed 0":                          func(config *stratum_hal.ChassisConfig) { findPort(t, 201, *scope).SpeedBps = 0 },
+		"port 2/2 (id 201) references missing node 9":            func(config *stratum_hal.ChassisConfig) { findPort(t, 201, *scope).Node = 9 },
+		"port 2/2 (id 201) is in slot 2 but node 1 is in slot 1": func(config *stratum_hal.ChassisConfig) { findPort(t, 201, *scope).Slot = 2 },
+		"trunk 3 (trunk-7) has the same id as port 3/0 (id 3)":   func(config *stratum_hal.ChassisConfig) { config.TrunkPorts[0].Id = 3 },
+		"trunk 7 (trunk-7) member 42 is not a singleton port":    func(config *stratum_hal.ChassisConfig) { config.TrunkPorts[0].Members[0] = 42 },
+		"chassis has no nodes":                                   func(config *stratum_hal.ChassisConfig) { config.Nodes = nil },
+		"chassis has no platform":                                func(config *stratum_hal.ChassisConfig) { config.Chassis = nil },
+	}
+	for violation, corrupt := range invalid {
+		config := render()
+		corrupt(config)
+		err := validateChassisConfig(onfSwitch, config)
+		if assert.Error(t, err, violation) {
+			assert.Contains(t, err.Error(), "Switch "+deviceTestLeafID+" ChassisConfig is invalid: ")
+			assert.Contains(t, err.Error(), violation)
+		}
+	}
+}
+
+// TestVerifyChassisConfig tests comparison of the ChassisConfig read back from a switch with the one pushed
+func TestVerifyChassisConfig(t *testing.T) {
+	s := Synchronizer{}
+	scope, onfSwitch, _ := newAppsScope()
+	assert.NoError(t, s.handleStratumSwitch(scope))
+	sent := &scope.StratumChassisConfig
+
+	var text bytes.Buffer
+	assert.NoError(t, proto.MarshalText(&text, sent))
+	assert.NoError(t, verifyChassisConfig(onfSwitch, sent, text.Bytes()))
+	binary, err := proto.Marshal(sent)
+	assert.NoError(t, err)
+	assert.NoError(t, verifyChassisConfig(onfSwitch, sent, binary))
+
+	adopted := proto.Clone(sent).(*stratum_hal.ChassisConfig)
+	adopted.SingletonPorts[0].SpeedBps = 1
+	adopted.SingletonPorts = append(adopted.SingletonPorts, &stratum_hal.SingletonPort{Id: 999})
+	adopted.Description = "other"
+	binary, err = proto.Marshal(adopted)
+	assert.NoError(t, err)
+	assert.EqualError(t, verifyChassisConfig(onfSwitch, sent, binary),
+		"Switch leaf-one ChassisConfig read back differs from the one pushed: description, port 201, port 999 was not sent")
+
+	adopted = proto.Clone(sent).(*stratum_hal.ChassisConfig)
+	adopted.SingletonPorts = nil
+	binary, err = proto.Marshal(adopted)
+	assert.NoError(t, err)
+	assert.EqualError(t, verifyChassisConfig(onfSwitch, sent, binary),
+		"Switch leaf-one ChassisConfig read back differs from the one pushed: port 201 is missing")
+
+	assert.Error(t, verifyChassisConfig(onfSwitch, sent, []byte("not a chassis config")))
+}
+
+// TestStratumVerify tests that a switch that does not adopt the pushed ChassisConfig counts as a push failure
+func TestStratumVerify(t *testing.T) {
+	tc := &testClient{expectedStatus: http.StatusOK}
+	GnmiPushClientFactory = func(dest string, target string, secure bool) Client { return tc }
+	defer func() { GnmiPushClientFactory = newClient }()
+
+	s := NewSynchronizer(WithStratumVerifyEnable(true))
+	scope, onfSwitch, _ := newAppsScope()
+	onfSwitch.ModelId = scope.SwitchModel.SwitchModelId
+	scope.Fabric.SwitchModel = map[string]*SwitchModel{*scope.SwitchModel.SwitchModelId: scope.SwitchModel}
+
+	failures, err := s.SynchronizeFabricToStratum(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failures)
+
+	// a mismatch on one switch does not stop the push to the next
+	otherSwitch := *onfSwitch
+	otherSwitch.SwitchId = aStr("leaf-two")
+	scope.Fabric.Switch["leaf-two"] = &otherSwitch
+	mismatches := testutil.ToFloat64(KpiStratumVerifyMismatchTotal.WithLabelValues(*scope.FabricId, deviceTestLeafID))
+
+	tc.getResponse = testGetResponse(&gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{BytesVal: []byte("description: \"other\"")}})
+	failures, err = s.SynchronizeFabricToStratum(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, failures)
+	assert.Equal(t, mismatches+1, testutil.ToFloat64(KpiStratumVerifyMismatchTotal.WithLabelValues(*scope.FabricId, deviceTestLeafID)))
+	// like a failed push, a mismatch renegotiates with the switch on the next sync
+	assert.False(t, s.switchCapabilities[deviceTestLeafID].connected)
+	assert.False(t, s.switchCapabilities["leaf-two"].connected)
+
+	s = NewSynchronizer()
+	failures, err = s.SynchronizeFabricToStratum(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failures)
+}
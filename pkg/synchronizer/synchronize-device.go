@@ -15,9 +15,13 @@ import (
 	"github.com/onosproject/config-models/models/sdn-fabric-0.1.x/api"
 	"github.com/onosproject/fabric-adapter/pkg/stratum_hal"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	gnmiapi "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/pkg/errors"
+	"net"
+	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -30,7 +34,7 @@ func (s *Synchronizer) handleSwitchPort(scope *FabricScope, p *Port) error {
 		return err
 	}
 
-	portID := switchCageChannelToDeviceId(sw, p.CageNumber, p.ChannelNumber)
+	portID := onosPortID(sw, model, p)
 
 	iface := onosInterface{
 		Name: *p.DisplayName,
@@ -59,6 +63,8 @@ func (s *Synchronizer) handleSwitchPort(scope *FabricScope, p *Port) error {
 	port := &onosPort{
 		Interfaces: []onosInterface{iface},
 	}
+	// always sent, so that re-enabling a port overwrites an earlier disable
+	port.Basic.Enabled = portAdminState(p) != stratum_hal.AdminState_ADMIN_STATE_DISABLED
 
 	scope.NetConfig.Ports[portID] = port
 
@@ -68,37 +74,58 @@ func (s *Synchronizer) handleSwitchPort(scope *FabricScope, p *Port) error {
 	return nil
 }
 
+// handleSwitchTrunk replaces the ports of a trunk with the aggregated interface, which ONOS
+// sees as a single port numbered with the trunk id
+func (s *Synchronizer) handleSwitchTrunk(scope *FabricScope, name string, fields map[string]string) error {
+	sw := scope.Switch
+
+	trunk, err := lookupSwitchTrunk(sw, scope.SwitchModel, name, fields)
+	if err != nil {
+		return err
+	}
+
+	memberIDs := []string{}
+	for _, p := range trunk.Members {
+		memberID := onosPortID(sw, scope.SwitchModel, p)
+		if _, okay := scope.NetConfig.Ports[memberID]; !okay {
+			return fmt.Errorf("Switch %s trunk %d member %s is not a configured port", *sw.SwitchId, trunk.ID, memberID)
+		}
+		memberIDs = append(memberIDs, memberID)
+	}
+
+	// members share their vlans, so the interfaces of any member describe the trunk
+	port := &onosPort{}
+	for _, iface := range scope.NetConfig.Ports[memberIDs[0]].Interfaces {
+		iface.Name = trunk.Name
+		port.Interfaces = append(port.Interfaces, iface)
+	}
+	port.Basic.Enabled = trunkAdminState(trunk) == stratum_hal.AdminState_ADMIN_STATE_ENABLED
+
+	for _, memberID := range memberIDs {
+		delete(scope.NetConfig.Ports, memberID)
+	}
+	scope.NetConfig.Ports[fmt.Sprintf("device:%s/%d", *sw.SwitchId, trunk.ID)] = port
+
+	return nil
+}
+
 func (s *Synchronizer) handleStratumSwitchPort(scope *FabricScope, p *Port) error {
 	model := scope.SwitchModel
 
-	// Make sure the port is in the model
-	_, err := lookupSwitchModelPort(model, p.CageNumber)
+	// Make sure the port is in the model, and the model port supports its channel and speed
+	modelPort, err := lookupSwitchModelPort(model, p.CageNumber)
+	if err != nil {
+		return err
+	}
+	err = validateSwitchPortCapabilities(scope.Switch, modelPort, p)
 	if err != nil {
 		return err
 	}
 
 	// Determine port speed
 	var autoneg = stratum_hal.TriState_TRI_STATE_FALSE
-	var speedBPS uint64
-	const gig = 10e8
-	switch p.Speed {
-	case api.OnfSdnFabricTypes_Speed_speed_100g:
-		speedBPS = 100 * gig
-	case api.OnfSdnFabricTypes_Speed_speed_10g:
-		speedBPS = 10 * gig
-	case api.OnfSdnFabricTypes_Speed_speed_1g:
-		speedBPS = gig
-	case api.OnfSdnFabricTypes_Speed_speed_2_5g:
-		speedBPS = 2.5 * gig
-	case api.OnfSdnFabricTypes_Speed_speed_25g:
-		speedBPS = 25 * gig
-	case api.OnfSdnFabricTypes_Speed_speed_400g:
-		speedBPS = 400 * gig
-	case api.OnfSdnFabricTypes_Speed_speed_40g:
-		speedBPS = 40 * gig
-	case api.OnfSdnFabricTypes_Speed_speed_5g:
-		speedBPS = 5 * gig
-	case api.OnfSdnFabricTypes_Speed_speed_autoneg:
+	speedBPS := speedToBps(p.Speed)
+	if p.Speed == api.OnfSdnFabricTypes_Speed_speed_autoneg {
 		autoneg = stratum_hal.TriState_TRI_STATE_TRUE
 		startingBPS, ok := scope.SwitchModel.Attribute["autoneg-starting-bandwidth"]
 		if ok {
@@ -107,28 +134,38 @@ func (s *Synchronizer) handleStratumSwitchPort(scope *FabricScope, p *Port) erro
 				return err
 			}
 		} else {
-			speedBPS = 10 * gig
+			speedBPS = speedToBps(api.OnfSdnFabricTypes_Speed_speed_10g)
 		}
 	}
 
 	// port configuration parameters
 	configParams := &stratum_hal.PortConfigParams{
-		AdminState: stratum_hal.AdminState_ADMIN_STATE_ENABLED,
+		AdminState: portAdminState(p),
 		Autoneg:    autoneg,
 	}
+	err = s.handleStratumSwitchPortParameters(scope, p, configParams)
+	if err != nil {
+		return err
+	}
 
-	// determine the id, slot, port and channel for the stratum model
-	slot := int32(1) // TODO if the switch has more than one line card, this will be wrong for all but the first card
+	// determine the id, slot, node, port and channel for the stratum model
+	_, nodes, err := lookupChassisNodes(model)
+	if err != nil {
+		return err
+	}
+	node, okay := nodes[*p.CageNumber]
+	if !okay {
+		return fmt.Errorf("SwitchModel has no node serving cage %d", *p.CageNumber)
+	}
+	slot := node.Slot
 	port := int32(*p.CageNumber)
 	channel := uint32(*p.ChannelNumber)
-	var id uint32
+	id := portNumber(model, *p.CageNumber, *p.ChannelNumber)
 	var name string
 
 	if channel != 0 {
-		id = (uint32(port) * 100) + (channel - 1)
 		name = fmt.Sprintf("Port %d/%d", port, channel-1)
 	} else {
-		id = uint32(port)
 		name = fmt.Sprintf("Port %d/0", port)
 	}
 
@@ -140,7 +177,7 @@ func (s *Synchronizer) handleStratumSwitchPort(scope *FabricScope, p *Port) erro
 		Port:         port,
 		Channel:      int32(channel),
 		SpeedBps:     speedBPS,
-		Node:         1,
+		Node:         node.Id,
 		ConfigParams: configParams,
 	}
 	scope.StratumChassisConfig.SingletonPorts = append(scope.StratumChassisConfig.SingletonPorts, singletonPort)
@@ -148,6 +185,255 @@ func (s *Synchronizer) handleStratumSwitchPort(scope *FabricScope, p *Port) erro
 	return nil
 }
 
+// handleStratumSwitchPortParameters sets the FEC mode, MTU and loopback mode of a port from the
+// fec, mtu and loopback parameters (see lookupPortParameter). The parameters override per-speed
+// defaults: RS-FEC on 25G and faster ports, as their optics require, and the default MTU everywhere.
+func (s *Synchronizer) handleStratumSwitchPortParameters(scope *FabricScope, p *Port, configParams *stratum_hal.PortConfigParams) error {
+	sw := scope.Switch
+	portName := fmt.Sprintf("%d/%d", *p.CageNumber, *p.ChannelNumber)
+
+	switch p.Speed {
+	case api.OnfSdnFabricTypes_Speed_speed_25g, api.OnfSdnFabricTypes_Speed_speed_100g, api.OnfSdnFabricTypes_Speed_speed_400g:
+		configParams.FecMode = stratum_hal.FecMode_FEC_MODE_ON
+	}
+	configParams.Mtu = defaultPortMtu
+
+	if value, ok := lookupPortParameter(sw, scope.SwitchModel, p, "fec"); ok {
+		fecMode, okay := stratum_hal.FecMode_value["FEC_MODE_"+strings.ToUpper(value)]
+		if !okay || stratum_hal.FecMode(fecMode) == stratum_hal.FecMode_FEC_MODE_UNKNOWN {
+			return fmt.Errorf("Switch %s port %s has invalid fec %s", *sw.SwitchId, portName, value)
+		}
+		configParams.FecMode = stratum_hal.FecMode(fecMode)
+	}
+
+	if value, ok := lookupPortParameter(sw, scope.SwitchModel, p, "mtu"); ok {
+		mtu, err := strconv.ParseUint(value, 10, 16)
+		if err != nil || mtu < minPortMtu {
+			return fmt.Errorf("Switch %s port %s has invalid mtu %s", *sw.SwitchId, portName, value)
+		}
+		configParams.Mtu = int32(mtu)
+	}
+
+	if value, ok := lookupPortParameter(sw, scope.SwitchModel, p, "loopback"); ok {
+		loopback, okay := stratum_hal.LoopbackState_value["LOOPBACK_STATE_"+strings.ToUpper(value)]
+		if !okay || stratum_hal.LoopbackState(loopback) == stratum_hal.LoopbackState_LOOPBACK_STATE_UNKNOWN {
+			return fmt.Errorf("Switch %s port %s has invalid loopback %s", *sw.SwitchId, portName, value)
+		}
+		configParams.LoopbackMode = stratum_hal.LoopbackState(loopback)
+	}
+
+	return nil
+}
+
+// handleSwitchLocation places the current switch in the ONOS GUI grid. The position is computed
+// from the fabric layout and may be overridden by the loc-type, grid-x and grid-y attributes. An
+// invalid coordinate is logged and the computed one kept.
+func (s *Synchronizer) handleSwitchLocation(scope *FabricScope, device *onosDevice) {
+	sw := scope.Switch
+
+	if scope.Fabric != nil {
+		if location, okay := computeGridLayout(scope.Fabric)[*sw.SwitchId]; okay {
+			device.Basic.LocType = onosLocTypeGrid
+			device.Basic.GridX = aUint16(location.X)
+			device.Basic.GridY = aUint16(location.Y)
+		}
+	}
+
+	if value, ok := lookupSwitchAttribute(sw, "loc-type"); ok {
+		device.Basic.LocType = value
+	}
+	// a coordinate is a pointer so that an explicit 0 is still sent
+	for attr, coordinate := range map[string]**uint16{"grid-x": &device.Basic.GridX, "grid-y": &device.Basic.GridY} {
+		value, ok := lookupSwitchAttribute(sw, attr)
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			// log the error and continue with the computed coordinate
+			log.Warnf("fabric %s switch %s has invalid %s attribute %s", *scope.FabricId, *sw.SwitchId, attr, value)
+			continue
+		}
+		*coordinate = aUint16(uint16(parsed))
+		if device.Basic.LocType == "" {
+			device.Basic.LocType = onosLocTypeGrid
+		}
+	}
+}
+
+// handleSwitchUp4 adds the current switch to the UP4 app config if its "up4" attribute is set
+func (s *Synchronizer) handleSwitchUp4(scope *FabricScope) error {
+	sw := scope.Switch
+
+	up4, ok := sw.Attribute["up4"]
+	if !ok || up4.Value == nil {
+		return nil
+	}
+	enabled, err := strconv.ParseBool(*up4.Value)
+	if err != nil {
+		return fmt.Errorf("fabric %s switch %s has invalid up4 attribute %s", *scope.FabricId, *sw.SwitchId, *up4.Value)
+	}
+	if !enabled {
+		return nil
+	}
+
+	if sw.Role != RoleLeaf {
+		return fmt.Errorf("fabric %s switch %s has up4 enabled but is not a leaf", *scope.FabricId, *sw.SwitchId)
+	}
+	pipeconf, ok := lookupSwitchAttribute(sw, "pipeconf")
+	if !ok {
+		return fmt.Errorf("fabric %s switch %s has up4 enabled but no pipeconf attribute", *scope.FabricId, *sw.SwitchId)
+	}
+	err = validateUp4Pipeconf(pipeconf)
+	if err != nil {
+		return fmt.Errorf("fabric %s switch %s has up4 enabled: %s", *scope.FabricId, *sw.SwitchId, err)
+	}
+
+	if scope.NetConfig.Apps == nil {
+		scope.NetConfig.Apps = map[string]*onosApp{}
+	}
+	up4App, okay := scope.NetConfig.Apps[onosUp4AppName]
+	if !okay {
+		up4App = &onosApp{
+			Up4: &onosUp4Config{
+				Devices: []string{},
+			},
+		}
+		scope.NetConfig.Apps[onosUp4AppName] = up4App
+	}
+	up4App.Up4.Devices = append(up4App.Up4.Devices, "device:"+*sw.SwitchId)
+
+	return nil
+}
+
+// handleSwitchTelemetry builds the INT report config from the "int.*" attributes of the current
+// switch. Reporting is fabric wide, so every switch that sets the attributes must agree.
+func (s *Synchronizer) handleSwitchTelemetry(scope *FabricScope) error {
+	sw := scope.Switch
+
+	collectorIP, ok := lookupSwitchAttribute(sw, telemetryAttributePrefix+".collector-ip")
+	if !ok {
+		return nil
+	}
+	if net.ParseIP(collectorIP) == nil {
+		return fmt.Errorf("fabric %s switch %s has invalid INT collector ip %s", *scope.FabricId, *sw.SwitchId, collectorIP)
+	}
+
+	report := &onosTelemetryReport{
+		CollectorIP:               collectorIP,
+		CollectorPort:             defaultTelemetryCollectorPort,
+		MinFlowHopLatencyChangeNs: defaultTelemetryMinFlowHopLatencyChangeNs,
+		WatchSubnets:              []string{},
+	}
+
+	if value, ok := lookupSwitchAttribute(sw, telemetryAttributePrefix+".collector-port"); ok {
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return fmt.Errorf("fabric %s switch %s has invalid INT collector port %s", *scope.FabricId, *sw.SwitchId, value)
+		}
+		report.CollectorPort = uint16(port)
+	}
+
+	if value, ok := lookupSwitchAttribute(sw, telemetryAttributePrefix+".min-flow-hop-latency-change-ns"); ok {
+		latency, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("fabric %s switch %s has invalid INT hop latency change %s", *scope.FabricId, *sw.SwitchId, value)
+		}
+		report.MinFlowHopLatencyChangeNs = uint32(latency)
+	}
+
+	if value, ok := lookupSwitchAttribute(sw, telemetryAttributePrefix+".watch-subnets"); ok && value != "" {
+		for _, subnet := range strings.Split(value, ",") {
+			_, _, err := net.ParseCIDR(subnet)
+			if err != nil {
+				return fmt.Errorf("fabric %s switch %s has invalid INT watch subnet %s", *scope.FabricId, *sw.SwitchId, subnet)
+			}
+			report.WatchSubnets = append(report.WatchSubnets, subnet)
+		}
+	}
+
+	queues := lookupAttributeGroups(sw, telemetryAttributePrefix+".queue")
+	for _, queueID := range sortedKeys(queues) {
+		id, err := strconv.ParseUint(queueID, 10, 8)
+		if err != nil || id > maxTelemetryQueueID {
+			return fmt.Errorf("fabric %s switch %s has invalid INT queue %s", *scope.FabricId, *sw.SwitchId, queueID)
+		}
+		trigger, err := strconv.ParseUint(queues[queueID]["trigger-ns"], 10, 32)
+		if err != nil {
+			return fmt.Errorf("fabric %s switch %s INT queue %s has invalid trigger-ns", *scope.FabricId, *sw.SwitchId, queueID)
+		}
+		threshold := onosTelemetryQueue{TriggerNs: uint32(trigger)}
+		if value, ok := queues[queueID]["reset-ns"]; ok {
+			reset, err := strconv.ParseUint(value, 10, 32)
+			if err != nil || reset > trigger {
+				return fmt.Errorf("fabric %s switch %s INT queue %s has invalid reset-ns", *scope.FabricId, *sw.SwitchId, queueID)
+			}
+			threshold.ResetNs = uint32(reset)
+		}
+		if report.QueueReportLatencyThresholds == nil {
+			report.QueueReportLatencyThresholds = map[string]onosTelemetryQueue{}
+		}
+		report.QueueReportLatencyThresholds[strconv.FormatUint(id, 10)] = threshold
+	}
+
+	if scope.NetConfig.Apps == nil {
+		scope.NetConfig.Apps = map[string]*onosApp{}
+	}
+	intApp, okay := scope.NetConfig.Apps[onosIntAppName]
+	if okay {
+		if !reflect.DeepEqual(intApp.TelemetryReport, report) {
+			return fmt.Errorf("fabric %s switch %s INT settings conflict with those of another switch", *scope.FabricId, *sw.SwitchId)
+		}
+		return nil
+	}
+	scope.NetConfig.Apps[onosIntAppName] = &onosApp{
+		TelemetryReport: report,
+	}
+
+	return nil
+}
+
+// handleSwitchComponentConfig collects the onos component properties set by the
+// "component.<component>.<property>" attributes of the current switch. Component properties
+// are controller wide, so every switch that sets a property must agree on its value. A conflicting
+// property keeps the value set first and is reported; the other properties are still collected.
+func (s *Synchronizer) handleSwitchComponentConfig(scope *FabricScope) error {
+	sw := scope.Switch
+
+	conflicts := []string{}
+	components := lookupAttributeGroups(sw, componentAttributePrefix)
+	for _, component := range sortedKeys(components) {
+		if scope.ComponentConfig == nil {
+			scope.ComponentConfig = map[string]OnosComponentConfig{}
+		}
+		config, okay := scope.ComponentConfig[component]
+		if !okay {
+			config = OnosComponentConfig{}
+			scope.ComponentConfig[component] = config
+		}
+		properties := []string{}
+		for property := range components[component] {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+		for _, property := range properties {
+			value := components[component][property]
+			existing, okay := config[property]
+			if okay && existing != value {
+				conflicts = append(conflicts, fmt.Sprintf("component %s property %s to %s, conflicting with %s",
+					component, property, value, existing))
+				continue
+			}
+			config[property] = value
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("fabric %s switch %s sets %s", *scope.FabricId, *sw.SwitchId, strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
 func (s *Synchronizer) handleSwitch(ctx context.Context, scope *FabricScope) error {
 	var err error
 
@@ -158,6 +444,9 @@ func (s *Synchronizer) handleSwitch(ctx context.Context, scope *FabricScope) err
 	if sw.Management == nil || sw.Management.Address == nil || sw.Management.PortNumber == nil {
 		return fmt.Errorf("fabric %s switch %s has no management address", *scope.FabricId, *sw.SwitchId)
 	}
+	if err = validatePortNumbering(scope.SwitchModel); err != nil {
+		return fmt.Errorf("fabric %s switch %s: %s", *scope.FabricId, *sw.SwitchId, err)
+	}
 
 	device := &onosDevice{}
 
@@ -168,10 +457,6 @@ func (s *Synchronizer) handleSwitch(ctx context.Context, scope *FabricScope) err
 	}
 
 	device.Basic.Driver = *driver.Value
-	device.SegmentRouting.Ipv4NodeSid, err = s.sidStore.Get(ctx, *sw.SwitchId)
-	if err != nil {
-		return fmt.Errorf("fabric %s switch %s unable to create SID: %s", *scope.FabricId, *sw.SwitchId, err)
-	}
 	device.SegmentRouting.IsEdgeRouter = sw.Role != RoleSpine
 	device.SegmentRouting.Ipv4Loopback = *sw.Management.Address
 	device.SegmentRouting.RouterMac, err = addressToMac(*sw.Management.Address)
@@ -184,8 +469,26 @@ func (s *Synchronizer) handleSwitch(ctx context.Context, scope *FabricScope) err
 		return errors.New("switch pipeconf attribute must be specified")
 	}
 	device.Basic.PipeConf = *pipeconf.Value
-	device.Basic.ManagementAddress = getStratumEndpointForNetcfg(*sw.Management.Address, *sw.Management.PortNumber)
-	// omit for now: locType, gridX, gridY
+	err = s.validateOnosDriverAndPipeconf(scope, device.Basic.Driver, device.Basic.PipeConf)
+	if err != nil {
+		return err
+	}
+	nodes, _, err := lookupChassisNodes(scope.SwitchModel)
+	if err == nil {
+		err = validateChassisNodes(nodes)
+	}
+	if err != nil {
+		return fmt.Errorf("fabric %s switch %s: %s", *scope.FabricId, *sw.SwitchId, err)
+	}
+	device.Basic.ManagementAddress = getStratumEndpointForNetcfg(*sw.Management.Address, *sw.Management.PortNumber, nodes[0].Id)
+
+	// Allocate the SID only once the switch is known to be valid, so a rejected switch does not use one up
+	device.SegmentRouting.Ipv4NodeSid, err = s.sidStore.Get(ctx, *sw.SwitchId)
+	if err != nil {
+		return fmt.Errorf("fabric %s switch %s unable to create SID: %s", *scope.FabricId, *sw.SwitchId, err)
+	}
+
+	s.handleSwitchLocation(scope, device)
 
 	// segmentRouting
 	// Ipv4 Node Sid, Ipv4 Loopback, Router Mac, Is Edge Router, Adjacency Sids
@@ -199,6 +502,24 @@ func (s *Synchronizer) handleSwitch(ctx context.Context, scope *FabricScope) err
 
 	scope.NetConfig.Devices["device:"+*sw.SwitchId] = device
 
+	err = s.handleSwitchUp4(scope)
+	if err != nil {
+		// log the error; the switch is still usable without UP4
+		log.Warn(err)
+	}
+
+	err = s.handleSwitchTelemetry(scope)
+	if err != nil {
+		// log the error; the switch is still usable without telemetry
+		log.Warn(err)
+	}
+
+	err = s.handleSwitchComponentConfig(scope)
+	if err != nil {
+		// log the error and keep the properties that did not conflict
+		log.Warn(err)
+	}
+
 	// Ports
 
 	for _, port := range sw.Port {
@@ -209,20 +530,30 @@ func (s *Synchronizer) handleSwitch(ctx context.Context, scope *FabricScope) err
 		}
 	}
 
+	trunks := lookupAttributeGroups(sw, trunkAttributePrefix)
+	for _, name := range sortedKeys(trunks) {
+		err := s.handleSwitchTrunk(scope, name, trunks[name])
+		if err != nil {
+			// log the error and continue with next trunk
+			log.Warn(err)
+		}
+	}
+
 	// Pairing
 
 	if (sw.SwitchPair != nil) && (sw.SwitchPair.PairedSwitch != nil) {
-		if (sw.SwitchPair.PairingPort == nil) || (len(sw.SwitchPair.PairingPort) == 0) {
-			log.Warnf("Switch %s has PairedSwitch but no PairingPorts", *sw.SwitchId)
-		} else if len(sw.SwitchPair.PairingPort) > 1 {
-			// limitation for now, only 1 pairing port
-			log.Warnf("Switch %s has PairedSwitch and has more than one PairingPort", *sw.SwitchId)
+		peer, err := validateSwitchPair(scope.Fabric, sw)
+		var pairLocalPort uint32
+		if err == nil {
+			// ONOS takes a single pairLocalPort, several pairing ports form a trunk
+			pairLocalPort, err = lookupPairLocalPort(sw, scope.SwitchModel)
+		}
+		if err != nil {
+			// log the error; the switch still works unpaired
+			log.Warn(err)
 		} else {
-			device.SegmentRouting.PairDeviceID = *sw.SwitchId
-
-			for _, pairingPort := range sw.SwitchPair.PairingPort {
-				device.SegmentRouting.PairLocalPort = cageChannelToPort(pairingPort.CageNumber, pairingPort.ChannelNumber)
-			}
+			device.SegmentRouting.PairDeviceID = "device:" + *peer.SwitchId
+			device.SegmentRouting.PairLocalPort = pairLocalPort
 		}
 	}
 
@@ -238,44 +569,380 @@ func (s *Synchronizer) handleStratumSwitch(scope *FabricScope) error {
 		return fmt.Errorf("fabric %s switch %s has no management address", *scope.FabricId, *sw.SwitchId)
 	}
 
+	if err := validatePortNumbering(scope.SwitchModel); err != nil {
+		return fmt.Errorf("fabric %s switch %s: %s", *scope.FabricId, *sw.SwitchId, err)
+	}
+
 	scope.StratumChassisConfig.Description = *sw.DisplayName
 
-	node := stratum_hal.Node{ // TODO is this right?
-		Id:    1,
-		Slot:  1,
-		Index: 1,
+	nodes, _, err := lookupChassisNodes(scope.SwitchModel)
+	if err == nil {
+		err = validateChassisNodes(nodes)
 	}
-	scope.StratumChassisConfig.Nodes = []*stratum_hal.Node{&node}
+	if err != nil {
+		return fmt.Errorf("fabric %s switch %s: %s", *scope.FabricId, *sw.SwitchId, err)
+	}
+	scope.StratumChassisConfig.Nodes = nodes
 
+	platform, err := lookupSwitchModelPlatform(scope.SwitchModel)
+	if err != nil {
+		return fmt.Errorf("fabric %s switch %s: %s", *scope.FabricId, *sw.SwitchId, err)
+	}
 	scope.StratumChassisConfig.Chassis = &stratum_hal.Chassis{
-		Platform: stratum_hal.Platform_PLT_GENERIC_BAREFOOT_TOFINO,
+		Platform: platform,
 		Name:     *sw.DisplayName,
 	}
 
-	// Ports
+	// Ports, in cage and channel order so that the config is deterministic
 
-	for _, port := range sw.Port {
-		err := s.handleStratumSwitchPort(scope, port)
+	for _, key := range sortedPortKeys(sw) {
+		err := s.handleStratumSwitchPort(scope, sw.Port[key])
 		if err != nil {
 			// log the error and continue with next port
 			log.Warn(err)
 		}
 	}
 
+	// Trunks
+
+	trunked := map[uint32]uint32{}
+	trunks := lookupAttributeGroups(sw, trunkAttributePrefix)
+	for _, name := range sortedKeys(trunks) {
+		err := s.handleStratumSwitchTrunk(scope, name, trunks[name], trunked)
+		if err != nil {
+			// log the error and continue with next trunk
+			log.Warn(err)
+		}
+	}
+
+	// a broken QoS profile skips the switch, rather than pushing it without its shaping
+	err = s.handleStratumSwitchVendorConfig(scope)
+	if err != nil {
+		return err
+	}
+
+	for _, trunk := range scope.StratumChassisConfig.TrunkPorts {
+		if trunk.Type == stratum_hal.TrunkPort_LACP_TRUNK {
+			lacpConfig, err := s.handleStratumSwitchLacp(scope)
+			if err != nil {
+				return err
+			}
+			scope.StratumChassisConfig.Chassis.ConfigParams = &stratum_hal.ChassisConfigParams{
+				LacpConfig: lacpConfig,
+			}
+			break
+		}
+	}
+
 	return nil
 }
 
-func (s *Synchronizer) handleRoute(scope *FabricScope, route *Route) error {
-	err := validateRoute(route)
+// handleStratumSwitchQos builds the Tofino QoS profile of the current switch from the qos-pool
+// and qos-queue attributes. Pools are named ingress-<n> or egress-<n> and have a size and an
+// optional color-drop flag. Queues have a priority (0-7) and optionally a weight, an egress pool
+// that must itself be configured, guaranteed-cells, max-rate-bps and min-rate-bps. Returns nil if
+// the switch has no QoS profile.
+func (s *Synchronizer) handleStratumSwitchQos(scope *FabricScope) (*stratum_hal.TofinoConfig_TofinoQosConfig, []*stratum_hal.TofinoConfig_TofinoQosConfig_QueueConfig_QueueMapping, error) {
+	sw := scope.Switch
+	pools := lookupQosAttributeGroups(sw, scope.SwitchModel, qosPoolAttributePrefix)
+	queues := lookupQosAttributeGroups(sw, scope.SwitchModel, qosQueueAttributePrefix)
+	if len(pools) == 0 && len(queues) == 0 {
+		return nil, nil, nil
+	}
+
+	qos := &stratum_hal.TofinoConfig_TofinoQosConfig{}
+	configured := map[stratum_hal.TofinoConfig_TofinoQosConfig_ApplicationPool]bool{}
+	for _, name := range sortedKeys(pools) {
+		fields := pools[name]
+		pool, okay := qosApplicationPool(name)
+		if !okay {
+			return nil, nil, fmt.Errorf("Switch %s has invalid QoS pool %s", *sw.SwitchId, name)
+		}
+		size, err := strconv.ParseUint(fields["size"], 10, 32)
+		if err != nil || size == 0 {
+			return nil, nil, fmt.Errorf("Switch %s QoS pool %s has invalid size %s", *sw.SwitchId, name, fields["size"])
+		}
+		poolConfig := &stratum_hal.TofinoConfig_TofinoQosConfig_PoolConfig{
+			Pool:     pool,
+			PoolSize: uint32(size),
+		}
+		if value, okay := fields["color-drop"]; okay {
+			poolConfig.EnableColorDrop, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Switch %s QoS pool %s has invalid color-drop %s", *sw.SwitchId, name, value)
+			}
+		}
+		configured[pool] = true
+		qos.PoolConfigs = append(qos.PoolConfigs, poolConfig)
+	}
+
+	mappings := []*stratum_hal.TofinoConfig_TofinoQosConfig_QueueConfig_QueueMapping{}
+	for _, name := range sortedKeys(queues) {
+		fields := queues[name]
+		id, err := strconv.ParseUint(name, 10, 8)
+		if err != nil || id > maxQosQueueID {
+			return nil, nil, fmt.Errorf("Switch %s has invalid QoS queue %s, queues are 0-%d", *sw.SwitchId, name, maxQosQueueID)
+		}
+		priority, err := strconv.ParseUint(fields["priority"], 10, 8)
+		if err != nil || priority > 7 {
+			return nil, nil, fmt.Errorf("Switch %s QoS queue %d has invalid priority %s", *sw.SwitchId, id, fields["priority"])
+		}
+		mapping := &stratum_hal.TofinoConfig_TofinoQosConfig_QueueConfig_QueueMapping{
+			QueueId:  int32(id),
+			Priority: stratum_hal.TofinoConfig_TofinoQosConfig_PRIO_0 + stratum_hal.TofinoConfig_TofinoQosConfig_SchedulingPriority(priority),
+		}
+		if value, okay := fields["pool"]; okay {
+			pool, okay := qosApplicationPool(value)
+			if !okay || !strings.HasPrefix(value, "egress-") {
+				return nil, nil, fmt.Errorf("Switch %s QoS queue %d pool %s is not an egress pool", *sw.SwitchId, id, value)
+			}
+			if !configured[pool] {
+				return nil, nil, fmt.Errorf("Switch %s QoS queue %d pool %s is not configured", *sw.SwitchId, id, value)
+			}
+			mapping.Pool = pool
+		}
+		for field, target := range map[string]*uint32{"weight": &mapping.Weight, "guaranteed-cells": &mapping.MinimumGuaranteedCells} {
+			if value, okay := fields[field]; okay {
+				parsed, err := strconv.ParseUint(value, 10, 32)
+				if err != nil {
+					return nil, nil, fmt.Errorf("Switch %s QoS queue %d has invalid %s %s", *sw.SwitchId, id, field, value)
+				}
+				*target = uint32(parsed)
+			}
+		}
+		if value, okay := fields["max-rate-bps"]; okay {
+			rate, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || rate == 0 {
+				return nil, nil, fmt.Errorf("Switch %s QoS queue %d has invalid max-rate-bps %s", *sw.SwitchId, id, value)
+			}
+			mapping.MaxRate = &stratum_hal.TofinoConfig_TofinoQosConfig_QueueConfig_QueueMapping_MaxRateBytes{
+				MaxRateBytes: &stratum_hal.TofinoConfig_ByteShape{RateBps: rate, BurstBytes: defaultShapingBurstBytes},
+			}
+		}
+		if value, okay := fields["min-rate-bps"]; okay {
+			rate, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || rate == 0 {
+				return nil, nil, fmt.Errorf("Switch %s QoS queue %d has invalid min-rate-bps %s", *sw.SwitchId, id, value)
+			}
+			mapping.MinRate = &stratum_hal.TofinoConfig_TofinoQosConfig_QueueConfig_QueueMapping_MinRateBytes{
+				MinRateBytes: &stratum_hal.TofinoConfig_ByteShape{RateBps: rate, BurstBytes: defaultShapingBurstBytes},
+			}
+		}
+		mappings = append(mappings, mapping)
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].QueueId < mappings[j].QueueId })
+
+	return qos, mappings, nil
+}
+
+// handleStratumSwitchVendorConfig adds the Tofino QoS profile and port shaping of the current
+// switch to the stratum config. The queues of the profile apply to every port. A port is shaped by
+// its shaping-rate-bps and shaping-burst-bytes parameters (see lookupPortParameter).
+func (s *Synchronizer) handleStratumSwitchVendorConfig(scope *FabricScope) error {
+	sw := scope.Switch
+
+	qos, mappings, err := s.handleStratumSwitchQos(scope)
 	if err != nil {
 		return err
 	}
 
-	oRoute := onosRoute{
-		Prefix:  *route.Prefix,
-		NextHop: *route.Address,
+	shaping := map[uint32]*stratum_hal.TofinoConfig_BfPortShapingConfig_BfPerPortShapingConfig{}
+	for _, p := range sw.Port {
+		value, ok := lookupPortParameter(sw, scope.SwitchModel, p, "shaping-rate-bps")
+		if !ok {
+			continue
+		}
+		rate, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || rate == 0 {
+			return fmt.Errorf("Switch %s port %d/%d has invalid shaping-rate-bps %s", *sw.SwitchId, *p.CageNumber, *p.ChannelNumber, value)
+		}
+		burst := uint64(defaultShapingBurstBytes)
+		if value, ok := lookupPortParameter(sw, scope.SwitchModel, p, "shaping-burst-bytes"); ok {
+			burst, err = strconv.ParseUint(value, 10, 32)
+			if err != nil || burst == 0 {
+				return fmt.Errorf("Switch %s port %d/%d has invalid shaping-burst-bytes %s", *sw.SwitchId, *p.CageNumber, *p.ChannelNumber, value)
+			}
+		}
+		shaping[portNumber(scope.SwitchModel, *p.CageNumber, *p.ChannelNumber)] = &stratum_hal.TofinoConfig_BfPortShapingConfig_BfPerPortShapingConfig{
+			Shaping: &stratum_hal.TofinoConfig_BfPortShapingConfig_BfPerPortShapingConfig_ByteShaping{
+				ByteShaping: &stratum_hal.TofinoConfig_ByteShape{RateBps: rate, BurstBytes: uint32(burst)},
+			},
+		}
+	}
+
+	if qos == nil && len(shaping) == 0 {
+		return nil
+	}
+	switch scope.StratumChassisConfig.Chassis.Platform {
+	case stratum_hal.Platform_PLT_GENERIC_BAREFOOT_TOFINO, stratum_hal.Platform_PLT_GENERIC_BAREFOOT_TOFINO2:
+	default:
+		return fmt.Errorf("Switch %s has QoS or shaping configured but platform %s is not Tofino",
+			*sw.SwitchId, scope.StratumChassisConfig.Chassis.Platform)
 	}
 
+	singletonPorts := append([]*stratum_hal.SingletonPort{}, scope.StratumChassisConfig.SingletonPorts...)
+	sort.Slice(singletonPorts, func(i, j int) bool { return singletonPorts[i].Id < singletonPorts[j].Id })
+
+	tofino := &stratum_hal.TofinoConfig{}
+	if qos != nil {
+		tofino.NodeIdToQosConfig = map[uint64]*stratum_hal.TofinoConfig_TofinoQosConfig{}
+		for _, node := range scope.StratumChassisConfig.Nodes {
+			tofino.NodeIdToQosConfig[node.Id] = &stratum_hal.TofinoConfig_TofinoQosConfig{PoolConfigs: qos.PoolConfigs}
+		}
+		for _, singletonPort := range singletonPorts {
+			if len(mappings) == 0 {
+				break
+			}
+			nodeQos := tofino.NodeIdToQosConfig[singletonPort.Node]
+			nodeQos.QueueConfigs = append(nodeQos.QueueConfigs, &stratum_hal.TofinoConfig_TofinoQosConfig_QueueConfig{
+				PortType:     &stratum_hal.TofinoConfig_TofinoQosConfig_QueueConfig_Port{Port: singletonPort.Id},
+				QueueMapping: mappings,
+			})
+		}
+	}
+	for _, singletonPort := range singletonPorts {
+		portShaping, okay := shaping[singletonPort.Id]
+		if !okay {
+			continue
+		}
+		if tofino.NodeIdToPortShapingConfig == nil {
+			tofino.NodeIdToPortShapingConfig = map[uint64]*stratum_hal.TofinoConfig_BfPortShapingConfig{}
+		}
+		nodeShaping, okay := tofino.NodeIdToPortShapingConfig[singletonPort.Node]
+		if !okay {
+			nodeShaping = &stratum_hal.TofinoConfig_BfPortShapingConfig{
+				PerPortShapingConfigs: map[uint32]*stratum_hal.TofinoConfig_BfPortShapingConfig_BfPerPortShapingConfig{},
+			}
+			tofino.NodeIdToPortShapingConfig[singletonPort.Node] = nodeShaping
+		}
+		nodeShaping.PerPortShapingConfigs[singletonPort.Id] = portShaping
+	}
+
+	scope.StratumChassisConfig.VendorConfig = &stratum_hal.VendorConfig{TofinoConfig: tofino}
+	return nil
+}
+
+// handleStratumSwitchTrunk adds a trunk to the stratum config. Members must be singleton ports
+// of the same node and may only be in one trunk; trunked records the trunk of each member.
+func (s *Synchronizer) handleStratumSwitchTrunk(scope *FabricScope, name string, fields map[string]string, trunked map[uint32]uint32) error {
+	sw := scope.Switch
+
+	trunk, err := lookupSwitchTrunk(sw, scope.SwitchModel, name, fields)
+	if err != nil {
+		return err
+	}
+
+	trunkPort := &stratum_hal.TrunkPort{
+		Id:   trunk.ID,
+		Name: trunk.Name,
+		Type: trunk.Type,
+		ConfigParams: &stratum_hal.PortConfigParams{
+			AdminState: trunkAdminState(trunk),
+		},
+	}
+	for _, p := range trunk.Members {
+		id := portNumber(scope.SwitchModel, *p.CageNumber, *p.ChannelNumber)
+		var member *stratum_hal.SingletonPort
+		for _, singletonPort := range scope.StratumChassisConfig.SingletonPorts {
+			if singletonPort.Id == id {
+				member = singletonPort
+			}
+		}
+		if member == nil {
+			return fmt.Errorf("Switch %s trunk %d member %d is not a configured port", *sw.SwitchId, trunk.ID, id)
+		}
+		if other, okay := trunked[id]; okay {
+			return fmt.Errorf("Switch %s trunk %d member %d is already in trunk %d", *sw.SwitchId, trunk.ID, id, other)
+		}
+		if trunkPort.Node != 0 && member.Node != trunkPort.Node {
+			return fmt.Errorf("Switch %s trunk %d has members on nodes %d and %d", *sw.SwitchId, trunk.ID, trunkPort.Node, member.Node)
+		}
+		trunkPort.Node = member.Node
+		trunkPort.Members = append(trunkPort.Members, id)
+	}
+
+	for _, id := range trunkPort.Members {
+		trunked[id] = trunk.ID
+	}
+	scope.StratumChassisConfig.TrunkPorts = append(scope.StratumChassisConfig.TrunkPorts, trunkPort)
+
+	return nil
+}
+
+// handleStratumSwitchLacp builds the LACP system ID of the switch from its router MAC and the
+// lacp-system-priority attribute
+func (s *Synchronizer) handleStratumSwitchLacp(scope *FabricScope) (*stratum_hal.ChassisConfigParams_LacpConfig, error) {
+	sw := scope.Switch
+
+	lacpConfig := &stratum_hal.ChassisConfigParams_LacpConfig{
+		LacpSystemPriority: defaultLacpSystemPriority,
+	}
+	if value, ok := lookupSwitchAttribute(sw, lacpSystemPriorityAttribute); ok {
+		priority, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("fabric %s switch %s has invalid %s attribute %s", *scope.FabricId, *sw.SwitchId, lacpSystemPriorityAttribute, value)
+		}
+		lacpConfig.LacpSystemPriority = uint32(priority)
+	}
+
+	// the same MAC as the segment routing routerMac, which is only stable for an IP management address
+	if net.ParseIP(*sw.Management.Address) == nil {
+		return nil, fmt.Errorf("fabric %s switch %s has LACP trunks but management address %s is not an IP address",
+			*scope.FabricId, *sw.SwitchId, *sw.Management.Address)
+	}
+	routerMac, err := addressToMac(*sw.Management.Address)
+	if err != nil {
+		return nil, fmt.Errorf("fabric %s switch %s unable to create LACP router MAC: %s", *scope.FabricId, *sw.SwitchId, err)
+	}
+	lacpConfig.LacpRouterMac, err = macToUint64(routerMac)
+	if err != nil {
+		return nil, err
+	}
+
+	return lacpConfig, nil
+}
+
+// handleRoutes adds the static routes to the route-service config. Routes that share a prefix
+// are grouped, and the next hops with the lowest metric are all emitted so that ONOS can
+// spread traffic across them.
+func (s *Synchronizer) handleRoutes(scope *FabricScope) {
+	type nextHops struct {
+		metric    uint8
+		addresses []string
+	}
+	byPrefix := map[string]*nextHops{}
+
+	for _, route := range scope.Fabric.Route {
+		err := validateRoute(route)
+		if err != nil {
+			// log the error and continue with next route
+			log.Warn(err)
+			continue
+		}
+
+		_, ipNet, _ := net.ParseCIDR(*route.Prefix)
+		prefix := ipNet.String()
+		nextHop := net.ParseIP(*route.Address).String()
+		metric := DerefUint8Ptr(route.Metric, 0)
+
+		hops, okay := byPrefix[prefix]
+		if !okay || metric < hops.metric {
+			byPrefix[prefix] = &nextHops{metric: metric, addresses: []string{nextHop}}
+		} else if metric == hops.metric {
+			hops.addresses = appendUnique(hops.addresses, nextHop)
+		}
+	}
+
+	if len(byPrefix) == 0 {
+		return
+	}
+
+	prefixes := []string{}
+	for prefix := range byPrefix {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
 	routeApp, okay := scope.NetConfig.Apps[onosRouteAppName]
 	if !okay {
 		routeApp = &onosApp{
@@ -284,13 +951,208 @@ func (s *Synchronizer) handleRoute(scope *FabricScope, route *Route) error {
 		scope.NetConfig.Apps[onosRouteAppName] = routeApp
 	}
 
-	routeApp.Routes = append(routeApp.Routes, oRoute)
+	for _, prefix := range prefixes {
+		addresses := byPrefix[prefix].addresses
+		sort.Strings(addresses)
+		for _, address := range addresses {
+			routeApp.Routes = append(routeApp.Routes, onosRoute{
+				Prefix:  prefix,
+				NextHop: address,
+			})
+		}
+	}
+}
+
+func (s *Synchronizer) handleDhcpServer(scope *FabricScope, server *DhcpServer) error {
+	err := validateDhcpServer(server)
+	if err != nil {
+		return err
+	}
+
+	connectPoints := lookupDhcpServerConnectPoints(scope, *server.DhcpServerId)
+	if len(connectPoints) == 0 {
+		return fmt.Errorf("DhcpServer %s is not the DhcpConnectPoint of any port", *server.DhcpServerId)
+	}
+
+	for _, cp := range connectPoints {
+		subnets, err := lookupPortSubnets(cp.Switch, cp.Port)
+		if err != nil {
+			return err
+		}
+		if !addressInSubnets(*server.Address, subnets) {
+			return fmt.Errorf("DhcpServer %s address %s is not reachable on any vlan subnet of %s",
+				*server.DhcpServerId, *server.Address, cp)
+		}
+
+		dhcpApp, okay := scope.NetConfig.Apps[onosDhcpRelayAppName]
+		if !okay {
+			dhcpApp = &onosApp{
+				DhcpDefault: []onosDhcpConfig{},
+			}
+			scope.NetConfig.Apps[onosDhcpRelayAppName] = dhcpApp
+		}
+
+		dhcpApp.DhcpDefault = append(dhcpApp.DhcpDefault, onosDhcpConfig{
+			ConnectPoint: cp.String(),
+			ServerIps:    []string{*server.Address},
+		})
+	}
+
+	return nil
+}
+
+// handleSwitchXconnect renders a segmentrouting cross-connect from the attributes
+// xconnect.<name>.vlan and xconnect.<name>.ports, where ports is "<cage>/<channel>,<cage>/<channel>".
+// Both ports must carry the vlan.
+func (s *Synchronizer) handleSwitchXconnect(scope *FabricScope, name string, fields map[string]string) error {
+	sw := scope.Switch
+
+	vlanID, err := strconv.ParseUint(fields["vlan"], 10, 16)
+	if err != nil {
+		return fmt.Errorf("Switch %s xconnect %s has invalid vlan %s", *sw.SwitchId, name, fields["vlan"])
+	}
+	_, err = lookupSwitchVlan(sw, aUint16(uint16(vlanID)))
+	if err != nil {
+		return err
+	}
+
+	portNames := strings.Split(fields["ports"], ",")
+	if len(portNames) != 2 || portNames[0] == portNames[1] {
+		return fmt.Errorf("Switch %s xconnect %s needs two different ports, got %s", *sw.SwitchId, name, fields["ports"])
+	}
+
+	xconnect := onosXconnect{
+		Name: name,
+		Vlan: uint16(vlanID),
+	}
+	for _, portName := range portNames {
+		p, err := lookupSwitchPort(sw, portName)
+		if err != nil {
+			return err
+		}
+		if !portCarriesVlan(p, xconnect.Vlan) {
+			return fmt.Errorf("Switch %s xconnect %s port %s does not carry vlan %d", *sw.SwitchId, name, portName, xconnect.Vlan)
+		}
+		xconnect.Ports = append(xconnect.Ports, portNumber(scope.SwitchModel, *p.CageNumber, *p.ChannelNumber))
+	}
+
+	srApp, okay := scope.NetConfig.Apps[onosSegmentRoutingApp]
+	if !okay {
+		srApp = &onosApp{}
+		scope.NetConfig.Apps[onosSegmentRoutingApp] = srApp
+	}
+	if srApp.Xconnect == nil {
+		srApp.Xconnect = map[string][]onosXconnect{}
+	}
+	deviceID := "device:" + *sw.SwitchId
+	srApp.Xconnect[deviceID] = append(srApp.Xconnect[deviceID], xconnect)
+
+	return nil
+}
+
+// handleSwitchHost adds a static host attached to the current switch. Hosts are described by
+// "host.<name>.<field>" switch attributes, with fields mac, ips, port and vlan. If the name is
+// the ID of a DhcpServer, the ips and port default to the server's address and connect points.
+func (s *Synchronizer) handleSwitchHost(scope *FabricScope, name string, fields map[string]string) error {
+	sw := scope.Switch
+
+	mac, err := net.ParseMAC(fields["mac"])
+	if err != nil {
+		return fmt.Errorf("Switch %s host %s has invalid mac %s", *sw.SwitchId, name, fields["mac"])
+	}
+
+	vlan := "None"
+	var hostVlan *SwitchVlan
+	if fields["vlan"] != "" {
+		vlanID, err := strconv.ParseUint(fields["vlan"], 10, 16)
+		if err != nil {
+			return fmt.Errorf("Switch %s host %s has invalid vlan %s", *sw.SwitchId, name, fields["vlan"])
+		}
+		hostVlan, err = lookupSwitchVlan(sw, aUint16(uint16(vlanID)))
+		if err != nil {
+			return err
+		}
+		vlan = fields["vlan"]
+	}
+
+	ips := []string{}
+	if fields["ips"] != "" {
+		ips = strings.Split(fields["ips"], ",")
+	}
+
+	connectPoints := []*connectPoint{}
+	if fields["port"] != "" {
+		p, err := lookupSwitchPort(sw, fields["port"])
+		if err != nil {
+			return err
+		}
+		connectPoints = append(connectPoints, &connectPoint{Switch: sw, Model: scope.SwitchModel, Port: p})
+	}
+
+	if server, okay := scope.Fabric.DhcpServer[name]; okay {
+		if len(ips) == 0 && server.Address != nil {
+			ips = append(ips, *server.Address)
+		}
+		if len(connectPoints) == 0 {
+			for _, cp := range lookupDhcpServerConnectPoints(scope, name) {
+				if cp.Switch == sw {
+					connectPoints = append(connectPoints, cp)
+				}
+			}
+		}
+	}
+
+	if len(ips) == 0 || len(connectPoints) == 0 {
+		return fmt.Errorf("Switch %s host %s needs both ips and a port", *sw.SwitchId, name)
+	}
+
+	locations := []string{}
+	for _, cp := range connectPoints {
+		// A host without a vlan is on the untagged vlan of its port
+		portVlan := hostVlan
+		if portVlan == nil {
+			if cp.Port.Vlans == nil || cp.Port.Vlans.Untagged == nil {
+				return fmt.Errorf("Switch %s host %s has no vlan and %s has no untagged vlan", *sw.SwitchId, name, cp)
+			}
+			portVlan, err = lookupSwitchVlan(sw, cp.Port.Vlans.Untagged)
+			if err != nil {
+				return err
+			}
+		} else if !portCarriesVlan(cp.Port, *portVlan.VlanId) {
+			return fmt.Errorf("Switch %s host %s port %s does not carry vlan %d", *sw.SwitchId, name, cp, *portVlan.VlanId)
+		}
+		for _, ip := range ips {
+			if !addressInSubnets(ip, portVlan.Subnet) {
+				return fmt.Errorf("Switch %s host %s address %s is not in the subnet of vlan %d on %s",
+					*sw.SwitchId, name, ip, *portVlan.VlanId, cp)
+			}
+		}
+		locations = append(locations, cp.String())
+	}
+
+	if scope.NetConfig.Hosts == nil {
+		scope.NetConfig.Hosts = map[string]*onosHost{}
+	}
+	hostID := fmt.Sprintf("%s/%s", strings.ToUpper(mac.String()), vlan)
+	host, okay := scope.NetConfig.Hosts[hostID]
+	if !okay {
+		host = &onosHost{}
+		host.Basic.Name = name
+		host.Basic.Ips = []string{}
+		host.Basic.Locations = []string{}
+		scope.NetConfig.Hosts[hostID] = host
+	}
+	// A dual-homed host is declared on both switches of a pair, so merge rather than replace
+	host.Basic.Ips = appendUnique(host.Basic.Ips, ips...)
+	host.Basic.Locations = appendUnique(host.Basic.Locations, locations...)
 
 	return nil
 }
 
 // SynchronizeFabricToOnos pushes a fabric to an onos netconfig
 func (s *Synchronizer) SynchronizeFabricToOnos(ctx context.Context, scope *FabricScope) (int, error) {
+	s.forgetFailedOnosCatalogs()
+
 	// be deterministic...
 	switchIDKeys := []string{}
 	for k := range scope.Fabric.Switch {
@@ -316,38 +1178,132 @@ nextSwitch:
 		}
 	}
 
-	for _, route := range scope.Fabric.Route {
-		err := s.handleRoute(scope, route)
+	s.handleRoutes(scope)
+
+	for _, k := range switchIDKeys {
+		scope.Switch = scope.Fabric.Switch[k]
+		// a switch without a model numbers its ports with the default scheme
+		scope.SwitchModel, _ = lookupSwitchModel(scope, scope.Switch.ModelId)
+		hosts := lookupAttributeGroups(scope.Switch, hostAttributePrefix)
+		for _, name := range sortedKeys(hosts) {
+			err := s.handleSwitchHost(scope, name, hosts[name])
+			if err != nil {
+				// log the error and continue with next host
+				log.Warn(err)
+			}
+		}
+
+		xconnects := lookupAttributeGroups(scope.Switch, xconnectAttributePrefix)
+		for _, name := range sortedKeys(xconnects) {
+			err := s.handleSwitchXconnect(scope, name, xconnects[name])
+			if err != nil {
+				// log the error and continue with next xconnect
+				log.Warn(err)
+			}
+		}
+	}
+
+	dhcpServerIDKeys := []string{}
+	for k := range scope.Fabric.DhcpServer {
+		dhcpServerIDKeys = append(dhcpServerIDKeys, k)
+	}
+	sort.Strings(dhcpServerIDKeys)
+
+	for _, k := range dhcpServerIDKeys {
+		err := s.handleDhcpServer(scope, scope.Fabric.DhcpServer[k])
 		if err != nil {
-			// log the error and continue with next route
+			// log the error and continue with next dhcp server
 			log.Warn(err)
 		}
 	}
 
+	if scope.OnosEndpoint == nil {
+		return 0, fmt.Errorf("Fabric %s has no netconfig endpoint to push to", *scope.FabricId)
+	}
+
+	// The netconfig is still pushed if applications are missing, ONOS applies it once they
+	// are activated. The failure makes sure we come back and check again.
+	failures := 0
+	err := s.ensureOnosApps(scope)
+	if err != nil {
+		log.Warn(err)
+		KpiSynchronizationFailedTotal.WithLabelValues(*scope.FabricId, "applications", *scope.OnosEndpoint).Inc()
+		failures = 1
+	}
+
+	// Likewise a switch whose driver and pipeconf could not be checked against the catalog
+	// is still pushed, and checked again on the next sync.
+	if s.onosCatalogFailed(scope) {
+		failures++
+	}
+
 	if s.partialUpdateEnable && s.CacheCheck(CacheModelNetConfig, *scope.FabricId, scope.NetConfig) {
 		log.Infof("Fabric %s netconfig has not changed", *scope.FabricId)
-		return 0, nil
+		return failures, nil
 	}
 
 	data, err := json.MarshalIndent(scope.NetConfig, "", "  ")
 	if err != nil {
-		return 0, fmt.Errorf("Fabric %s failed to Marshal netconfig Json: %s", *scope.FabricId, err)
-	}
-
-	if scope.OnosEndpoint == nil {
-		return 0, fmt.Errorf("Fabric %s has no netconfig endpoint to push to", *scope.FabricId)
+		return failures, fmt.Errorf("Fabric %s failed to Marshal netconfig Json: %s", *scope.FabricId, err)
 	}
 
 	url := fmt.Sprintf("%sonos/v1/network/configuration", *scope.OnosEndpoint)
 	restPusher := NewRestPusher(url, *scope.OnosUsername, *scope.OnosPassword, data)
+	KpiOnosPushTotal.WithLabelValues(*scope.FabricId, CacheModelNetConfig, *scope.OnosEndpoint).Inc()
 	err = restPusher.PushUpdate()
 	if err != nil {
-		return 1, fmt.Errorf("Fabric %s failed to Push netconfig update: %s", *scope.FabricId, err)
+		KpiSynchronizationFailedTotal.WithLabelValues(*scope.FabricId, CacheModelNetConfig, *scope.OnosEndpoint).Inc()
+		return failures + 1, fmt.Errorf("Fabric %s failed to Push netconfig update: %s", *scope.FabricId, err)
 	}
 
 	s.CacheUpdate(CacheModelNetConfig, *scope.FabricId, scope.NetConfig)
 
-	return 0, nil
+	return failures, nil
+}
+
+// SynchronizeComponentConfigToOnos pushes the onos component configuration collected by
+// SynchronizeFabricToOnos, one component at a time
+func (s *Synchronizer) SynchronizeComponentConfigToOnos(scope *FabricScope) (int, error) {
+	if scope.OnosEndpoint == nil {
+		return 0, fmt.Errorf("Fabric %s has no component config endpoint to push to", *scope.FabricId)
+	}
+
+	components := []string{}
+	for component := range scope.ComponentConfig {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	pushFailures := 0
+	for _, component := range components {
+		config := scope.ComponentConfig[component]
+		cacheID := fmt.Sprintf("%s-%s", *scope.FabricId, component)
+		if s.partialUpdateEnable && s.CacheCheck(CacheModelComponentConfig, cacheID, config) {
+			log.Infof("Fabric %s component %s config has not changed", *scope.FabricId, component)
+			continue
+		}
+
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return pushFailures, fmt.Errorf("Fabric %s failed to Marshal component %s config Json: %s", *scope.FabricId, component, err)
+		}
+
+		url := fmt.Sprintf("%sonos/v1/configuration/%s", *scope.OnosEndpoint, component)
+		restPusher := NewRestPusher(url, *scope.OnosUsername, *scope.OnosPassword, data)
+		KpiOnosPushTotal.WithLabelValues(*scope.FabricId, CacheModelComponentConfig, *scope.OnosEndpoint).Inc()
+		err = restPusher.PushUpdate()
+		if err != nil {
+			KpiSynchronizationFailedTotal.WithLabelValues(*scope.FabricId, CacheModelComponentConfig, *scope.OnosEndpoint).Inc()
+			// log the error and continue with next component; the retry loop will try again
+			log.Warnf("Fabric %s failed to Push component %s config: %s", *scope.FabricId, component, err)
+			pushFailures++
+			continue
+		}
+
+		s.CacheUpdate(CacheModelComponentConfig, cacheID, config)
+	}
+
+	return pushFailures, nil
 }
 
 func useSecureTransport(sw *Switch) bool {
@@ -371,6 +1327,7 @@ func (s *Synchronizer) SynchronizeFabricToStratum(scope *FabricScope) (int, erro
 	}
 	sort.Strings(switchIDKeys)
 
+	pushFailures := 0
 nextSwitch:
 	for _, k := range switchIDKeys {
 		var err error
@@ -387,30 +1344,77 @@ nextSwitch:
 
 		err = s.handleStratumSwitch(scope)
 		if err != nil {
-			// log the error and continue with next switch
+			// log the error and continue with next switch, a partial config must not be pushed
 			log.Warn(err)
+			continue nextSwitch
 		}
 
-		var protoStringBytes bytes.Buffer
-		err = proto.MarshalText(&protoStringBytes, &scope.StratumChassisConfig)
+		err = validateChassisConfig(scope.Switch, &scope.StratumChassisConfig)
 		if err != nil {
-			return 1, err
+			// log the error and continue with next switch, the switch would reject the config
+			log.Warn(err)
+			continue nextSwitch
 		}
-		protoString := protoStringBytes.String()
-		log.Warnf("proto string for switch %s is:\n%s\n", *scope.Switch.SwitchId, protoString)
 
-		// Push proto
+		// Negotiate the encoding of the chassis config with the switch
 		stratumURI := getStratumEndpoint(*scope.Switch.Management.Address, *scope.Switch.Management.PortNumber)
 		log.Warnf("stratum URI %s", stratumURI)
-		gnmiPusher := NewGNMIPusher(stratumURI, "", protoString, "", scope.SecureTransport)
-		err = gnmiPusher.PushUpdate()
+		pushClient := GnmiPushClientFactory(stratumURI, "", scope.SecureTransport)
+		capabilities, err := s.lookupSwitchCapabilities(scope, stratumURI, pushClient)
+		if err != nil {
+			// log the error and continue with next switch. An unreachable switch is retried, a
+			// refused switch is not until its config changes.
+			log.Warn(err)
+			if _, unreachable := err.(*PushError); unreachable {
+				pushFailures++
+			} else {
+				KpiStratumRefusedTotal.WithLabelValues(*scope.FabricId, *scope.Switch.SwitchId).Inc()
+			}
+			continue nextSwitch
+		}
+		encoding := gnmiapi.Encoding(gnmiapi.Encoding_value[capabilities.Encoding])
+
+		var protoString string
+		if encoding == gnmiapi.Encoding_PROTO {
+			protoBytes, err := proto.Marshal(&scope.StratumChassisConfig)
+			if err != nil {
+				return 1, err
+			}
+			protoString = string(protoBytes)
+		} else {
+			var protoStringBytes bytes.Buffer
+			err = proto.MarshalText(&protoStringBytes, &scope.StratumChassisConfig)
+			if err != nil {
+				return 1, err
+			}
+			protoString = protoStringBytes.String()
+			log.Warnf("proto string for switch %s is:\n%s\n", *scope.Switch.SwitchId, protoString)
+		}
 
+		// Push proto
+		gnmiPusher := NewGNMIPusherWithEncoding(stratumURI, "", protoString, capabilities.Path, encoding, pushClient)
+		err = gnmiPusher.PushUpdate()
+		if err == nil && s.stratumVerifyEnable {
+			// a switch that did not adopt the config counts as a failed push, so it is retried
+			var readback []byte
+			readback, err = gnmiPusher.Readback()
+			if err == nil {
+				err = verifyChassisConfig(scope.Switch, &scope.StratumChassisConfig, readback)
+				if err != nil {
+					KpiStratumVerifyMismatchTotal.WithLabelValues(*scope.FabricId, *scope.Switch.SwitchId).Inc()
+				}
+			}
+		}
 		if err != nil {
-			return 1, err
+			// log the error and continue with next switch, renegotiating with this one next time
+			log.Warn(err)
+			s.forgetSwitchConnection(*scope.Switch.SwitchId)
+			pushFailures++
+			continue nextSwitch
 		}
 	}
 
-	return 0, nil
+	return pushFailures, nil
 }
 
 // SynchronizeDevice synchronizes a device. Two sets of error state are returned:
@@ -423,7 +1427,7 @@ func (s *Synchronizer) SynchronizeDevice(ctx context.Context, allConfig *gnmi.Co
 
 		log.Info("SynchronizeDevce")
 
-		controllerInfo, err := lookupFabricControllerInfo(ctx, s, fabricID)
+		controllerInfos, err := lookupFabricControllers(ctx, s, fabricID)
 		if err != nil {
 			return 0, err
 		}
@@ -431,28 +1435,20 @@ func (s *Synchronizer) SynchronizeDevice(ctx context.Context, allConfig *gnmi.Co
 		tStart := time.Now()
 		KpiSynchronizationTotal.WithLabelValues(fabricID).Inc()
 
-		uri := fmt.Sprintf("http://%s:%d/", controllerInfo.ControlEndpoint.Address, controllerInfo.ControlEndpoint.Port)
-
-		log.Info("controller uri: %s", uri)
 		scope := &FabricScope{
-			FabricId:        &fabricID,
-			Fabric:          device,
-			OnosEndpoint:    aStr(uri),
-			OnosUsername:    aStr(controllerInfo.Username),
-			OnosPassword:    aStr(controllerInfo.Password),
-			StratumEndpoint: aStr(uri),
+			FabricId: &fabricID,
+			Fabric:   device,
 			NetConfig: &OnosNetConfig{
 				Devices: map[string]*onosDevice{},
 				Ports:   map[string]*onosPort{},
+				Hosts:   map[string]*onosHost{},
 				Apps:    map[string]*onosApp{},
 			},
+			ComponentConfig: map[string]OnosComponentConfig{},
 			SecureTransport: false,
 		}
 
-		pushFailures, err := s.SynchronizeFabricToOnos(ctx, scope)
-		if err != nil {
-			log.Warnf("Failed to push fabric to ONOS %s: %v", fabricID, err)
-		}
+		pushFailures, pushComponentFailures := s.pushFabricToOnosCluster(ctx, scope, newOnosControllers(controllerInfos))
 
 		pushStratumFailures, err := s.SynchronizeFabricToStratum(scope)
 		if err != nil {
@@ -460,6 +1456,7 @@ func (s *Synchronizer) SynchronizeDevice(ctx context.Context, allConfig *gnmi.Co
 		}
 
 		pushFailuresTotal += pushFailures
+		pushFailuresTotal += pushComponentFailures
 		pushFailuresTotal += pushStratumFailures
 		KpiSynchronizationDuration.WithLabelValues(fabricID).Observe(time.Since(tStart).Seconds())
 	}
@@ -96,24 +96,45 @@ func (s *Synchronizer) GetModels() *gnmi.Model {
 
 // Start the synchronizer by launching the synchronizer loop inside a thread.
 func (s *Synchronizer) Start() {
-	log.Infof("Synchronizer starting (postEnable=%v, postTimeout=%d, retryInterval=%s, partialUpdateEnable=%v)",
+	log.Infof("Synchronizer starting (postEnable=%v, postTimeout=%d, retryInterval=%s, partialUpdateEnable=%v, appActivateEnable=%v, stratumVerifyEnable=%v)",
 		s.postEnable,
 		s.postTimeout,
 		s.retryInterval,
-		s.partialUpdateEnable)
+		s.partialUpdateEnable,
+		s.appActivateEnable,
+		s.stratumVerifyEnable)
 
 	atomixClient := atomix.NewClient(atomix.WithClientID(os.Getenv("POD_NAME")))
 
 	// TODO: Eventually we'll create a thread here that waits for config changes
 	var err error
-	s.sidStore, err = store.NewAtomixStore(context.Background(), atomixClient)
+	s.sidStore, err = store.NewAtomixStore(context.Background(), atomixClient, store.WithSIDRange(s.sidRangeStart, s.sidRangeEnd))
 	if err != nil {
 		log.Errorf("Can't create SID store: %v", err)
 		return
 	}
+	go s.logSIDChanges(context.Background())
 	go s.Loop()
 }
 
+// GetSIDStore returns the SID store, or nil if the synchronizer has not been started
+func (s *Synchronizer) GetSIDStore() store.SIDStore {
+	return s.sidStore
+}
+
+// logSIDChanges logs the SID inventory and every allocation change for diagnostic purposes
+func (s *Synchronizer) logSIDChanges(ctx context.Context) {
+	ch := make(chan store.SIDEvent)
+	err := s.sidStore.Watch(ctx, ch, true)
+	if err != nil {
+		log.Warnf("Unable to watch SID allocations: %v", err)
+		return
+	}
+	for event := range ch {
+		log.Infof("SID %s: switch %s SID %d", event.Type, event.Entry.SwitchID, event.Entry.SID)
+	}
+}
+
 // WithPostEnable sets the postEnable option
 func WithPostEnable(postEnable bool) SynchronizerOption {
 	return func(s *Synchronizer) {
@@ -135,6 +156,20 @@ func WithPartialUpdateEnable(partialUpdateEnable bool) SynchronizerOption {
 	}
 }
 
+// WithAppActivateEnable sets the appActivateEnable option
+func WithAppActivateEnable(appActivateEnable bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.appActivateEnable = appActivateEnable
+	}
+}
+
+// WithStratumVerifyEnable sets the stratumVerifyEnable option
+func WithStratumVerifyEnable(stratumVerifyEnable bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.stratumVerifyEnable = stratumVerifyEnable
+	}
+}
+
 // WithTopoEndpoint specifies the onos-topo endpoint to use
 func WithTopoEndpoint(topoEndpoint string) SynchronizerOption {
 	return func(s *Synchronizer) {
@@ -142,6 +177,14 @@ func WithTopoEndpoint(topoEndpoint string) SynchronizerOption {
 	}
 }
 
+// WithSIDRange sets the inclusive range that segment routing node SIDs are allocated from
+func WithSIDRange(start uint32, end uint32) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.sidRangeStart = start
+		s.sidRangeEnd = end
+	}
+}
+
 // WithCertPaths defines certificate paths
 func WithCertPaths(caPath string, keyPath string, certPath string) SynchronizerOption {
 	return func(s *Synchronizer) {
@@ -156,11 +199,17 @@ func NewSynchronizer(opts ...SynchronizerOption) *Synchronizer {
 	s := &Synchronizer{
 		postEnable:          true,
 		partialUpdateEnable: DefaultPartialUpdateEnable,
+		appActivateEnable:   DefaultAppActivateEnable,
+		stratumVerifyEnable: DefaultStratumVerifyEnable,
 		postTimeout:         DefaultPostTimeout,
 		updateChannel:       make(chan *ConfigUpdate, 1),
 		retryInterval:       5 * time.Second,
 		cache:               map[string]interface{}{},
 		prometheus:          map[string]*metrics.Fetcher{},
+		activeControllers:   map[string]string{},
+		onosCatalogs:        map[string]*onosCatalog{},
+		sidRangeStart:       store.DefaultSIDRangeStart,
+		sidRangeEnd:         store.DefaultSIDRangeEnd,
 
 		kafkaMsgChannel:   make(chan string, 10),
 		kafkaErrorChannel: make(chan error, 10),
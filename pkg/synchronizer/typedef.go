@@ -26,3 +26,7 @@ const RoleUnset = models.OnfSwitch_Switch_Role_UNSET         //nolint
 const RoleUndefined = models.OnfSwitch_Switch_Role_undefined //nolint
 const RoleLeaf = models.OnfSwitch_Switch_Role_leaf           //nolint
 const RoleSpine = models.OnfSwitch_Switch_Role_spine         //nolint
+
+const PortAdminStatusUp = models.OnfSwitch_Switch_Port_State_AdminStatus_UP           //nolint
+const PortAdminStatusDown = models.OnfSwitch_Switch_Port_State_AdminStatus_DOWN       //nolint
+const PortAdminStatusTesting = models.OnfSwitch_Switch_Port_State_AdminStatus_TESTING //nolint
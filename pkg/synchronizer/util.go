@@ -8,7 +8,12 @@ package synchronizer
 
 import (
 	"fmt"
+	"github.com/onosproject/config-models/models/sdn-fabric-0.1.x/api"
+	"github.com/onosproject/fabric-adapter/pkg/stratum_hal"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // BoolToUint32 convert a boolean to an unsigned integer
@@ -103,17 +108,166 @@ func aUint64(u uint64) *uint64 {
 	return &u
 }
 
-// cageChannelToPort converts a cage and channel to a single port integer
-func cageChannelToPort(cage *uint8, channel *uint8) uint16 {
-	if cage == nil {
-		return uint16(*channel)
+// speedToBps returns the bandwidth of a port speed in bits per second, or 0 for autoneg
+func speedToBps(speed api.E_OnfSdnFabricTypes_Speed) uint64 {
+	const gig = 10e8
+	switch speed {
+	case api.OnfSdnFabricTypes_Speed_speed_400g:
+		return 400 * gig
+	case api.OnfSdnFabricTypes_Speed_speed_100g:
+		return 100 * gig
+	case api.OnfSdnFabricTypes_Speed_speed_40g:
+		return 40 * gig
+	case api.OnfSdnFabricTypes_Speed_speed_25g:
+		return 25 * gig
+	case api.OnfSdnFabricTypes_Speed_speed_10g:
+		return 10 * gig
+	case api.OnfSdnFabricTypes_Speed_speed_5g:
+		return 5 * gig
+	case api.OnfSdnFabricTypes_Speed_speed_2_5g:
+		return 2.5 * gig
+	case api.OnfSdnFabricTypes_Speed_speed_1g:
+		return gig
+	default:
+		return 0
 	}
-	return uint16(*channel)*100 + uint16(*cage)
 }
 
-func switchCageChannelToDeviceId(sw *Switch, cage *uint8, channel *uint8) string {
-	port := cageChannelToPort(cage, channel)
-	return fmt.Sprintf("device:%s/%d", *sw.SwitchId, port)
+// connectPoint is a switch port that a host or server is attached to
+type connectPoint struct {
+	Switch *Switch
+	Model  *SwitchModel // numbers the port, may be nil for the default numbering
+	Port   *Port
+}
+
+// String returns the connect point in ONOS "device:<switch>/<port>" form
+func (cp *connectPoint) String() string {
+	return onosPortID(cp.Switch, cp.Model, cp.Port)
+}
+
+// sortedSwitchIDs returns the switch IDs of a fabric in a deterministic order
+func sortedSwitchIDs(fabric *RootDevice) []string {
+	switchIDKeys := []string{}
+	for k := range fabric.Switch {
+		switchIDKeys = append(switchIDKeys, k)
+	}
+	sort.Strings(switchIDKeys)
+	return switchIDKeys
+}
+
+// sortedPortKeys returns the port keys of a switch ordered by cage and channel
+func sortedPortKeys(sw *Switch) []SwitchPortKey {
+	keys := []SwitchPortKey{}
+	for k := range sw.Port {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CageNumber != keys[j].CageNumber {
+			return keys[i].CageNumber < keys[j].CageNumber
+		}
+		return keys[i].ChannelNumber < keys[j].ChannelNumber
+	})
+	return keys
+}
+
+// sortedKeys returns the keys of a string map in a deterministic order
+func sortedKeys(m map[string]map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// gridLocation is the position of a switch in the ONOS GUI grid
+type gridLocation struct {
+	X uint16
+	Y uint16
+}
+
+// computeGridLayout places spines in the top row and leaves below them, with the leaves of a
+// pair next to each other. Switches with no role go in a row of their own. Each row is centered
+// on the widest one.
+func computeGridLayout(fabric *RootDevice) map[string]gridLocation {
+	var spines, leaves, others []string
+	placed := map[string]bool{}
+	for _, swID := range sortedSwitchIDs(fabric) {
+		sw := fabric.Switch[swID]
+		switch sw.Role {
+		case RoleSpine:
+			spines = append(spines, swID)
+		case RoleLeaf:
+			if placed[swID] {
+				continue
+			}
+			leaves = append(leaves, swID)
+			placed[swID] = true
+			if sw.SwitchPair != nil && sw.SwitchPair.PairedSwitch != nil {
+				peerID := *sw.SwitchPair.PairedSwitch
+				peer, okay := fabric.Switch[peerID]
+				if okay && peer.Role == RoleLeaf && !placed[peerID] {
+					leaves = append(leaves, peerID)
+					placed[peerID] = true
+				}
+			}
+		default:
+			others = append(others, swID)
+		}
+	}
+
+	widest := len(spines)
+	if len(leaves) > widest {
+		widest = len(leaves)
+	}
+	if len(others) > widest {
+		widest = len(others)
+	}
+
+	layout := map[string]gridLocation{}
+	placeRow := func(row []string, y uint16) {
+		offset := (widest - len(row)) * gridColumnSpacing / 2
+		for i, swID := range row {
+			layout[swID] = gridLocation{X: uint16(offset + (i+1)*gridColumnSpacing), Y: y}
+		}
+	}
+	placeRow(spines, gridSpineRow)
+	placeRow(leaves, gridLeafRow)
+	placeRow(others, gridOtherRow)
+
+	return layout
+}
+
+// appendUnique appends the values that are not already in the slice
+func appendUnique(slice []string, values ...string) []string {
+nextValue:
+	for _, v := range values {
+		for _, existing := range slice {
+			if existing == v {
+				continue nextValue
+			}
+		}
+		slice = append(slice, v)
+	}
+	return slice
+}
+
+// addressInSubnets returns true if the address falls within one of the given subnets
+func addressInSubnets(address string, subnets []string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, subnet := range subnets {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			continue
+		}
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
 }
 
 func addressToMac(address string) (string, error) {
@@ -128,6 +282,19 @@ func addressToMac(address string) (string, error) {
 	}
 }
 
+// macToUint64 packs a MAC address into the 6 least significant bytes of an integer
+func macToUint64(mac string) (uint64, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return 0, err
+	}
+	var value uint64
+	for _, b := range hw {
+		value = value<<8 | uint64(b)
+	}
+	return value, nil
+}
+
 var nextIP uint32 = 0
 
 func managementAddressToIP(address string) string {
@@ -146,7 +313,87 @@ func getStratumEndpoint(addr string, port uint16) string {
 	return endpoint
 }
 
-func getStratumEndpointForNetcfg(addr string, port uint16) string {
-	endpoint := fmt.Sprintf("grpc://%s:%d?device_id=1", addr, port)
+// getStratumEndpointForNetcfg returns the ONOS management address of a switch. The device_id
+// is the P4Runtime device ID, which Stratum takes from the node ID.
+func getStratumEndpointForNetcfg(addr string, port uint16, nodeID uint64) string {
+	endpoint := fmt.Sprintf("grpc://%s:%d?device_id=%d", addr, port, nodeID)
 	return endpoint
 }
+
+// parseCageList parses a comma separated list of cages and cage ranges, such as "1-16,33"
+func parseCageList(list string) ([]uint8, error) {
+	cages := []uint8{}
+	for _, item := range strings.Split(list, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(item), "-", 2)
+		first, err := strconv.ParseUint(bounds[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cage list %s", list)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.ParseUint(bounds[1], 10, 8)
+			if err != nil || last < first {
+				return nil, fmt.Errorf("invalid cage list %s", list)
+			}
+		}
+		for cage := first; cage <= last; cage++ {
+			cages = append(cages, uint8(cage))
+		}
+	}
+	return cages, nil
+}
+
+// portAdminState returns the Stratum admin state for a port. Ports with no state are enabled.
+func portAdminState(p *Port) stratum_hal.AdminState {
+	if p.State == nil {
+		return stratum_hal.AdminState_ADMIN_STATE_ENABLED
+	}
+	switch p.State.AdminStatus {
+	case PortAdminStatusDown:
+		return stratum_hal.AdminState_ADMIN_STATE_DISABLED
+	case PortAdminStatusTesting:
+		return stratum_hal.AdminState_ADMIN_STATE_DIAG
+	default:
+		return stratum_hal.AdminState_ADMIN_STATE_ENABLED
+	}
+}
+
+// trunkAdminState returns the Stratum admin state for a trunk, which is enabled if any of its
+// members is enabled and disabled otherwise
+func trunkAdminState(trunk *switchTrunk) stratum_hal.AdminState {
+	for _, p := range trunk.Members {
+		if portAdminState(p) == stratum_hal.AdminState_ADMIN_STATE_ENABLED {
+			return stratum_hal.AdminState_ADMIN_STATE_ENABLED
+		}
+	}
+	return stratum_hal.AdminState_ADMIN_STATE_DISABLED
+}
+
+// portCarriesVlan returns true if the vlan is tagged or untagged on the port
+func portCarriesVlan(p *Port, vlan uint16) bool {
+	if p.Vlans == nil {
+		return false
+	}
+	if p.Vlans.Untagged != nil && *p.Vlans.Untagged == vlan {
+		return true
+	}
+	for _, tagged := range p.Vlans.Tagged {
+		if tagged == vlan {
+			return true
+		}
+	}
+	return false
+}
+
+// qosApplicationPool converts a pool name such as "egress-0" to a Tofino application pool
+func qosApplicationPool(name string) (stratum_hal.TofinoConfig_TofinoQosConfig_ApplicationPool, bool) {
+	parts := strings.SplitN(name, "-", 2)
+	if len(parts) != 2 {
+		return stratum_hal.TofinoConfig_TofinoQosConfig_UNKNOWN_APP_POOL, false
+	}
+	value, okay := stratum_hal.TofinoConfig_TofinoQosConfig_ApplicationPool_value[strings.ToUpper(parts[0])+"_APP_POOL_"+parts[1]]
+	if !okay || value == 0 {
+		return stratum_hal.TofinoConfig_TofinoQosConfig_UNKNOWN_APP_POOL, false
+	}
+	return stratum_hal.TofinoConfig_TofinoQosConfig_ApplicationPool(value), true
+}
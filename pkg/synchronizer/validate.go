@@ -8,6 +8,13 @@ package synchronizer
 
 import (
 	"fmt"
+	"github.com/gogo/protobuf/proto"
+	"github.com/onosproject/config-models/models/sdn-fabric-0.1.x/api"
+	"github.com/onosproject/fabric-adapter/pkg/stratum_hal"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
 )
 
 // Validation functions, return an error if the given struct is missing data that
@@ -20,5 +27,355 @@ func validateRoute(route *Route) error {
 	if (route.Address == nil) || (*route.Address == "") {
 		return fmt.Errorf("Route %s has no Address", *route.RouteId)
 	}
+	_, prefix, err := net.ParseCIDR(*route.Prefix)
+	if err != nil {
+		return fmt.Errorf("Route %s Prefix %s is not a valid prefix", *route.RouteId, *route.Prefix)
+	}
+	nextHop := net.ParseIP(*route.Address)
+	if nextHop == nil {
+		return fmt.Errorf("Route %s Address %s is not a valid IP address", *route.RouteId, *route.Address)
+	}
+	if (prefix.IP.To4() == nil) != (nextHop.To4() == nil) {
+		return fmt.Errorf("Route %s Prefix %s and Address %s are of different IP versions", *route.RouteId, *route.Prefix, *route.Address)
+	}
+	return nil
+}
+
+func validateDhcpServer(server *DhcpServer) error {
+	if (server.Address == nil) || (*server.Address == "") {
+		return fmt.Errorf("DhcpServer %s has no Address", *server.DhcpServerId)
+	}
+	if net.ParseIP(*server.Address) == nil {
+		return fmt.Errorf("DhcpServer %s Address %s is not a valid IP address", *server.DhcpServerId, *server.Address)
+	}
+	return nil
+}
+
+// validateUp4Pipeconf checks that a pipeconf is a fabric-tna profile that includes the UPF
+// tables, e.g. org.stratumproject.fabric-upf.montara_sde_9_7_0 or
+// org.stratumproject.fabric-upf-int.stratum_bfrt.mavericks_sde_9_7_0
+func validateUp4Pipeconf(pipeconf string) error {
+	profile := strings.TrimPrefix(pipeconf, "org.stratumproject.")
+	if profile == pipeconf || !strings.HasPrefix(profile, "fabric-upf") {
+		return fmt.Errorf("pipeconf %s is not a UPF-capable fabric-tna profile", pipeconf)
+	}
+	return nil
+}
+
+// pairingPortKeys returns the pairing ports of a switch ordered by cage and channel
+func pairingPortKeys(sw *Switch) []SwitchPortKey {
+	keys := []SwitchPortKey{}
+	if sw.SwitchPair == nil {
+		return keys
+	}
+	for k := range sw.SwitchPair.PairingPort {
+		keys = append(keys, SwitchPortKey{CageNumber: k.CageNumber, ChannelNumber: k.ChannelNumber})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CageNumber != keys[j].CageNumber {
+			return keys[i].CageNumber < keys[j].CageNumber
+		}
+		return keys[i].ChannelNumber < keys[j].ChannelNumber
+	})
+	return keys
+}
+
+// vlanSummary reduces the vlans of a switch to their IDs and sorted subnets for comparison
+func vlanSummary(sw *Switch) map[uint16][]string {
+	summary := map[uint16][]string{}
+	for id, vlan := range sw.Vlan {
+		subnets := append([]string{}, vlan.Subnet...)
+		sort.Strings(subnets)
+		summary[id] = subnets
+	}
+	return summary
+}
+
+// portVlanSummary reduces the vlans of a port to a string for comparison
+func portVlanSummary(p *Port) string {
+	if p.Vlans == nil {
+		return "none"
+	}
+	untagged := "none"
+	if p.Vlans.Untagged != nil {
+		untagged = fmt.Sprintf("%d", *p.Vlans.Untagged)
+	}
+	tagged := append([]uint16{}, p.Vlans.Tagged...)
+	sort.Slice(tagged, func(i, j int) bool { return tagged[i] < tagged[j] })
+	return fmt.Sprintf("untagged %s tagged %v", untagged, tagged)
+}
+
+// validateChassisNodes checks that a switch model has a single node. ONOS manages a switch as one
+// P4Runtime device, so the ports of any other node would be pushed to Stratum but never managed.
+func validateChassisNodes(nodes []*stratum_hal.Node) error {
+	if len(nodes) > 1 {
+		return fmt.Errorf("SwitchModel has %d nodes, but ONOS manages a switch as a single device", len(nodes))
+	}
+	return nil
+}
+
+// validateSwitchPair checks that both leaves of a pair reference each other through the same
+// pairing ports, and that they carry the same vlans
+func validateSwitchPair(fabric *RootDevice, sw *Switch) (*Switch, error) {
+	peerID := *sw.SwitchPair.PairedSwitch
+	if sw.Role != RoleLeaf {
+		return nil, fmt.Errorf("Switch %s is paired but is not a leaf", *sw.SwitchId)
+	}
+	if fabric == nil {
+		return nil, fmt.Errorf("Switch %s paired switch %s not found", *sw.SwitchId, peerID)
+	}
+	peer, okay := fabric.Switch[peerID]
+	if !okay {
+		return nil, fmt.Errorf("Switch %s paired switch %s not found", *sw.SwitchId, peerID)
+	}
+	if peer.Role != RoleLeaf {
+		return nil, fmt.Errorf("Switch %s paired switch %s is not a leaf", *sw.SwitchId, peerID)
+	}
+	if peer.SwitchPair == nil || peer.SwitchPair.PairedSwitch == nil || *peer.SwitchPair.PairedSwitch != *sw.SwitchId {
+		return nil, fmt.Errorf("Switch %s paired switch %s is not paired back with it", *sw.SwitchId, peerID)
+	}
+
+	localPorts := pairingPortKeys(sw)
+	if len(localPorts) == 0 {
+		return nil, fmt.Errorf("Switch %s has PairedSwitch but no PairingPorts", *sw.SwitchId)
+	}
+	for _, key := range localPorts {
+		if _, okay := sw.Port[key]; !okay {
+			return nil, fmt.Errorf("Switch %s pairing port %d/%d does not exist", *sw.SwitchId, key.CageNumber, key.ChannelNumber)
+		}
+	}
+	if !reflect.DeepEqual(localPorts, pairingPortKeys(peer)) {
+		return nil, fmt.Errorf("Switch %s pairing ports do not match those of paired switch %s", *sw.SwitchId, peerID)
+	}
+
+	if !reflect.DeepEqual(vlanSummary(sw), vlanSummary(peer)) {
+		return nil, fmt.Errorf("Switch %s vlans do not match those of paired switch %s", *sw.SwitchId, peerID)
+	}
+
+	return peer, nil
+}
+
+// validateSwitchPortCapabilities checks a port against the capabilities of its cage in the switch
+// model. The channel may not exceed the max-channel of the cage and the speed must be one the cage
+// supports. A cage is either used whole, as channel 0, or broken out, in which case the channels
+// together may not be faster than the cage. Models that leave out max-channel or speeds are not
+// checked for them.
+func validateSwitchPortCapabilities(sw *Switch, modelPort *SwitchModelPort, p *Port) error {
+	cage, channel := *p.CageNumber, *p.ChannelNumber
+
+	if modelPort.MaxChannel != nil && channel > *modelPort.MaxChannel {
+		return fmt.Errorf("Switch %s port %d/%d channel exceeds the %d channels of the cage",
+			*sw.SwitchId, cage, channel, *modelPort.MaxChannel)
+	}
+
+	if len(modelPort.Speeds) == 0 || p.Speed == api.OnfSdnFabricTypes_Speed_speed_autoneg {
+		return nil
+	}
+	var cageBps uint64
+	supported := false
+	for _, speed := range modelPort.Speeds {
+		if speed == p.Speed {
+			supported = true
+		}
+		if speedToBps(speed) > cageBps {
+			cageBps = speedToBps(speed)
+		}
+	}
+	if !supported {
+		return fmt.Errorf("Switch %s port %d/%d speed %s is not supported by the cage", *sw.SwitchId, cage, channel, p.Speed)
+	}
+
+	var totalBps uint64
+	for _, other := range sw.Port {
+		if *other.CageNumber != cage {
+			continue
+		}
+		if (*other.ChannelNumber == 0) != (channel == 0) {
+			return fmt.Errorf("Switch %s port %d/%d and port %d/%d use cage %d both whole and broken out",
+				*sw.SwitchId, cage, channel, cage, *other.ChannelNumber, cage)
+		}
+		totalBps += speedToBps(other.Speed)
+	}
+	if totalBps > cageBps {
+		return fmt.Errorf("Switch %s port %d/%d channels of cage %d need %d bps, more than the %d bps of the cage",
+			*sw.SwitchId, cage, channel, cage, totalBps, cageBps)
+	}
+
+	return nil
+}
+
+// validateChassisConfig checks the structure of a rendered ChassisConfig before it is pushed, so
+// that mistakes are reported against the switch and port instead of as a gNMI Set error. Every
+// violation is reported, not only the first.
+func validateChassisConfig(sw *Switch, config *stratum_hal.ChassisConfig) error {
+	violations := []string{}
+	violation := func(format string, a ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, a...))
+	}
+
+	if config.Chassis == nil || config.Chassis.Platform == stratum_hal.Platform_PLT_UNKNOWN {
+		violation("chassis has no platform")
+	}
+
+	if len(config.Nodes) == 0 {
+		violation("chassis has no nodes")
+	}
+	nodes := map[uint64]*stratum_hal.Node{}
+	for _, node := range config.Nodes {
+		if node.Id == 0 {
+			violation("node has id 0")
+			continue
+		}
+		if _, okay := nodes[node.Id]; okay {
+			violation("node %d is duplicated", node.Id)
+		}
+		if node.Slot <= 0 {
+			violation("node %d has invalid slot %d", node.Id, node.Slot)
+		}
+		nodes[node.Id] = node
+	}
+
+	portIDs := map[uint32]string{}
+	locations := map[string]string{}
+	singletonPorts := map[uint32]*stratum_hal.SingletonPort{}
+	for _, sp := range config.SingletonPorts {
+		portName := fmt.Sprintf("port %d/%d (id %d)", sp.Port, sp.Channel, sp.Id)
+		if sp.Id == 0 {
+			violation("%s has id 0", portName)
+		} else if other, okay := portIDs[sp.Id]; okay {
+			violation("%s has the same id as %s", portName, other)
+		} else {
+			portIDs[sp.Id] = portName
+			singletonPorts[sp.Id] = sp
+		}
+		if sp.SpeedBps == 0 {
+			violation("%s has speed 0", portName)
+		}
+		if sp.Port <= 0 || sp.Channel < 0 {
+			violation("%s has an invalid port or channel", portName)
+		}
+		node, okay := nodes[sp.Node]
+		if !okay {
+			violation("%s references missing node %d", portName, sp.Node)
+		} else if node.Slot != sp.Slot {
+			violation("%s is in slot %d but node %d is in slot %d", portName, sp.Slot, sp.Node, node.Slot)
+		}
+		location := fmt.Sprintf("%d/%d/%d", sp.Slot, sp.Port, sp.Channel)
+		if other, okay := locations[location]; okay {
+			violation("%s has the same slot, port and channel as %s", portName, other)
+		}
+		locations[location] = portName
+	}
+
+	trunked := map[uint32]uint32{}
+	for _, tp := range config.TrunkPorts {
+		trunkName := fmt.Sprintf("trunk %d (%s)", tp.Id, tp.Name)
+		if tp.Id == 0 {
+			violation("%s has id 0", trunkName)
+		} else if other, okay := portIDs[tp.Id]; okay {
+			violation("%s has the same id as %s", trunkName, other)
+		} else {
+			portIDs[tp.Id] = trunkName
+		}
+		if _, okay := nodes[tp.Node]; !okay {
+			violation("%s references missing node %d", trunkName, tp.Node)
+		}
+		if len(tp.Members) == 0 {
+			violation("%s has no members", trunkName)
+		}
+		for _, member := range tp.Members {
+			sp, okay := singletonPorts[member]
+			if !okay {
+				violation("%s member %d is not a singleton port", trunkName, member)
+				continue
+			}
+			if sp.Node != tp.Node {
+				violation("%s member %s is on node %d, not node %d", trunkName, portIDs[member], sp.Node, tp.Node)
+			}
+			if other, okay := trunked[member]; okay {
+				violation("%s member %s is already a member of trunk %d", trunkName, portIDs[member], other)
+			}
+			trunked[member] = tp.Id
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("Switch %s ChassisConfig is invalid: %s", *sw.SwitchId, strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// verifyChassisConfig checks that the ChassisConfig read back from a switch, in text or binary
+// form, is the one that was pushed. Differences are reported per section and per port.
+func verifyChassisConfig(sw *Switch, sent *stratum_hal.ChassisConfig, readback []byte) error {
+	adopted := &stratum_hal.ChassisConfig{}
+	if err := proto.UnmarshalText(string(readback), adopted); err != nil {
+		adopted.Reset()
+		if err := proto.Unmarshal(readback, adopted); err != nil {
+			return fmt.Errorf("Switch %s ChassisConfig read back is not a ChassisConfig: %s", *sw.SwitchId, err)
+		}
+	}
+
+	differences := []string{}
+	if sent.Description != adopted.Description {
+		differences = append(differences, "description")
+	}
+	if !proto.Equal(sent.Chassis, adopted.Chassis) {
+		differences = append(differences, "chassis")
+	}
+	if len(sent.Nodes) != len(adopted.Nodes) {
+		differences = append(differences, "nodes")
+	} else {
+		for i := range sent.Nodes {
+			if !proto.Equal(sent.Nodes[i], adopted.Nodes[i]) {
+				differences = append(differences, fmt.Sprintf("node %d", sent.Nodes[i].Id))
+			}
+		}
+	}
+
+	sentPorts := map[uint32]proto.Message{}
+	adoptedPorts := map[uint32]proto.Message{}
+	for _, sp := range sent.SingletonPorts {
+		sentPorts[sp.Id] = sp
+	}
+	for _, sp := range adopted.SingletonPorts {
+		adoptedPorts[sp.Id] = sp
+	}
+	for _, tp := range sent.TrunkPorts {
+		sentPorts[tp.Id] = tp
+	}
+	for _, tp := range adopted.TrunkPorts {
+		adoptedPorts[tp.Id] = tp
+	}
+	portIDs := []uint32{}
+	for id := range sentPorts {
+		portIDs = append(portIDs, id)
+	}
+	for id := range adoptedPorts {
+		if _, okay := sentPorts[id]; !okay {
+			portIDs = append(portIDs, id)
+		}
+	}
+	sort.Slice(portIDs, func(i, j int) bool { return portIDs[i] < portIDs[j] })
+	for _, id := range portIDs {
+		sentPort, wasSent := sentPorts[id]
+		adoptedPort, wasAdopted := adoptedPorts[id]
+		switch {
+		case !wasAdopted:
+			differences = append(differences, fmt.Sprintf("port %d is missing", id))
+		case !wasSent:
+			differences = append(differences, fmt.Sprintf("port %d was not sent", id))
+		case !proto.Equal(sentPort, adoptedPort):
+			differences = append(differences, fmt.Sprintf("port %d", id))
+		}
+	}
+
+	if !proto.Equal(sent.VendorConfig, adopted.VendorConfig) {
+		differences = append(differences, "vendor config")
+	}
+
+	if len(differences) > 0 {
+		return fmt.Errorf("Switch %s ChassisConfig read back differs from the one pushed: %s", *sw.SwitchId, strings.Join(differences, ", "))
+	}
 	return nil
 }